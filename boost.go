@@ -0,0 +1,276 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Link functions applied on the sum of the contributions of an Ensemble
+const (
+	// IdentityLink predicts the sum itself (regression, squared error)
+	IdentityLink = "identity"
+	// LogisticLink predicts a probability 1 / (1 + exp(-sum)) (binary classification, log loss)
+	LogisticLink = "logistic"
+)
+
+// BoostOptions allow to configure the training of an Ensemble
+type BoostOptions struct {
+	// Rounds is the number of trees (100 by default)
+	Rounds int
+	// LearningRate shrinks the contribution of each tree (0.1 by default)
+	LearningRate float64
+	// MaxDepth of each tree (3 by default)
+	MaxDepth int
+	// MinSamplesLeaf is the minimum number of samples on each branch of a split (1 by default)
+	MinSamplesLeaf int
+	// Link is IdentityLink (by default) or LogisticLink
+	Link string
+	// Features are the keys of the requests that can be used, all of them by default
+	Features []string
+}
+
+// Ensemble is a gradient boosted model: the prediction is the link function applied on the
+// Base score plus the sum of the contributions held by the nodes selected on each tree
+type Ensemble struct {
+	Base  float64
+	Link  string
+	Trees []*Tree
+}
+
+// Contribution is the value added by one tree of an Ensemble
+type Contribution struct {
+	Tree   int     `json:"tree"`
+	NodeID int     `json:"node_id"`
+	Value  float64 `json:"value"`
+}
+
+// Explanation details the prediction of an Ensemble for one request
+type Explanation struct {
+	Base          float64        `json:"base"`
+	Contributions []Contribution `json:"contributions"`
+	// Score is the Base plus the sum of the contributions
+	Score float64 `json:"score"`
+	// Prediction is the link function applied on the Score
+	Prediction float64 `json:"prediction"`
+}
+
+// gradient is the label learned by the trees of a boosting round
+type gradient struct {
+	residual float64
+	hessian  float64
+}
+
+// boosting learns the contribution of a tree: the Newton step on the gradients, shrunk by the learning rate
+type boosting struct {
+	rate float64
+}
+
+func (boosting) newStats() targetStats {
+	return &varianceStats{}
+}
+
+func (b boosting) content(rows []trainRow) interface{} {
+	var residuals, hessians float64
+	for _, r := range rows {
+		g := r.label.(gradient)
+		residuals += r.weight * g.residual
+		hessians += r.weight * g.hessian
+	}
+
+	if hessians <= 1e-12 {
+		return 0.0
+	}
+	return b.rate * residuals / hessians
+}
+
+func (boosting) name(content interface{}) string {
+	return fmt.Sprintf("%+.4g", content.(float64))
+}
+
+// TrainBoosted trains a gradient boosted ensemble: each round fits a regression tree on the
+// residuals of the previous rounds. With LogisticLink the labels must be bools or 0/1 numbers,
+// with IdentityLink numbers.
+// Every node of the trees holds its contribution (a float64) on its Content.
+func TrainBoosted(samples []Sample, options ...func(o *BoostOptions)) (*Ensemble, error) {
+	config := &BoostOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+	if config.Rounds <= 0 {
+		config.Rounds = 100
+	}
+	if config.LearningRate <= 0 {
+		config.LearningRate = 0.1
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 3
+	}
+	if config.Link == "" {
+		config.Link = IdentityLink
+	}
+	if config.Link != IdentityLink && config.Link != LogisticLink {
+		return nil, fmt.Errorf("unknown link %q", config.Link)
+	}
+
+	labels := make([]float64, len(samples))
+	var sum float64
+	for i, s := range samples {
+		switch l := s.Label.(type) {
+		case float64:
+			labels[i] = l
+		case bool:
+			if l {
+				labels[i] = 1
+			}
+		default:
+			return nil, ErrBadLabel
+		}
+		if config.Link == LogisticLink && labels[i] != 0 && labels[i] != 1 {
+			return nil, ErrBadLabel
+		}
+		sum += labels[i]
+	}
+
+	e := &Ensemble{Link: config.Link, Base: sum / float64(len(samples))}
+	if config.Link == LogisticLink {
+		p := math.Min(math.Max(e.Base, 1e-6), 1-1e-6)
+		e.Base = math.Log(p / (1 - p))
+	}
+
+	scores := make([]float64, len(samples))
+	for i := range scores {
+		scores[i] = e.Base
+	}
+
+	treeOptions := &TrainOptions{
+		MaxDepth:       config.MaxDepth,
+		MinSamplesLeaf: config.MinSamplesLeaf,
+		Features:       config.Features,
+	}
+
+	rows := make([]Sample, len(samples))
+	for round := 0; round < config.Rounds; round++ {
+		for i, s := range samples {
+			g := gradient{residual: labels[i] - scores[i], hessian: 1}
+			if config.Link == LogisticLink {
+				p := link(LogisticLink, scores[i])
+				g = gradient{residual: labels[i] - p, hessian: math.Max(p*(1-p), 1e-12)}
+			}
+			rows[i] = Sample{Request: s.Request, Label: g}
+		}
+
+		options := *treeOptions
+		t, err := induce(rows, &options, boosting{rate: config.LearningRate})
+		if err != nil {
+			return nil, err
+		}
+		e.Trees = append(e.Trees, t)
+
+		for i, s := range samples {
+			v, err := t.Predict(s.Request)
+			if err != nil {
+				return nil, err
+			}
+			scores[i] += v
+		}
+	}
+
+	return e, nil
+}
+
+func link(name string, score float64) float64 {
+	if name == LogisticLink {
+		return 1 / (1 + math.Exp(-score))
+	}
+	return score
+}
+
+// Explain resolves the request on every tree and details the contribution of each one
+func (e *Ensemble) Explain(request map[string]interface{}, options ...func(t *TreeOptions)) (*Explanation, error) {
+	x := &Explanation{Base: e.Base, Score: e.Base}
+	for i, t := range e.Trees {
+		node, err := t.Resolve(request, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := PredictionValue(node)
+		if err != nil {
+			return nil, fmt.Errorf("tree %d: %v", i, err)
+		}
+
+		x.Contributions = append(x.Contributions, Contribution{Tree: i, NodeID: node.ID, Value: v})
+		x.Score += v
+	}
+	x.Prediction = link(e.Link, x.Score)
+
+	return x, nil
+}
+
+// Score returns the Base plus the sum of the contributions of the trees
+func (e *Ensemble) Score(request map[string]interface{}, options ...func(t *TreeOptions)) (float64, error) {
+	x, err := e.Explain(request, options...)
+	if err != nil {
+		return 0, err
+	}
+	return x.Score, nil
+}
+
+// Predict returns the link function applied on the Score
+func (e *Ensemble) Predict(request map[string]interface{}, options ...func(t *TreeOptions)) (float64, error) {
+	x, err := e.Explain(request, options...)
+	if err != nil {
+		return 0, err
+	}
+	return x.Prediction, nil
+}
+
+type jsonEnsemble struct {
+	Base  float64  `json:"base"`
+	Link  string   `json:"link"`
+	Trees [][]Tree `json:"trees"`
+}
+
+// MarshalJSON encodes the ensemble, each tree on the json format read by LoadTree
+func (e *Ensemble) MarshalJSON() ([]byte, error) {
+	j := jsonEnsemble{Base: e.Base, Link: e.Link, Trees: make([][]Tree, len(e.Trees))}
+	for i, t := range e.Trees {
+		j.Trees[i] = t.Flatten()
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes an ensemble
+func (e *Ensemble) UnmarshalJSON(b []byte) error {
+	var j jsonEnsemble
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	e.Base, e.Link = j.Base, j.Link
+	if e.Link == "" {
+		e.Link = IdentityLink
+	}
+	e.Trees = make([]*Tree, len(j.Trees))
+	for i := range j.Trees {
+		e.Trees[i] = CreateTree(j.Trees[i])
+	}
+
+	return nil
+}
+
+// LoadEnsemble gets a json and builds the Ensemble related
+func LoadEnsemble(jsonEnsemble []byte) (*Ensemble, error) {
+	e := &Ensemble{}
+	if err := json.Unmarshal(jsonEnsemble, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
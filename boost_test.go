@@ -0,0 +1,98 @@
+package dtree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainBoosted_Regression(t *testing.T) {
+	// Arrange
+	samples := deliverySamples()
+
+	// Act
+	e, err := TrainBoosted(samples, func(o *BoostOptions) {
+		o.Rounds = 50
+		o.LearningRate = 0.3
+		o.MaxDepth = 2
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, e.Trees, 50)
+	assert.Equal(t, 37.5, e.Base)
+	assert.IsType(t, 0.0, e.Trees[0].GetChild()[0].Content, "the nodes hold their contribution")
+
+	v, err := e.Predict(map[string]interface{}{"distance": 15.0, "city": "lyon"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 55, v, 0.01)
+
+	x, err := e.Explain(map[string]interface{}{"distance": 1.0, "city": "paris"})
+	assert.NoError(t, err)
+	assert.Len(t, x.Contributions, 50)
+	sum := x.Base
+	for _, c := range x.Contributions {
+		sum += c.Value
+	}
+	assert.InDelta(t, sum, x.Score, 1e-9)
+	assert.Equal(t, x.Score, x.Prediction)
+	assert.InDelta(t, 20, x.Prediction, 0.01)
+}
+
+func TestTrainBoosted_Logistic(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+	for i := range samples {
+		samples[i].Label = samples[i].Label == "yes"
+	}
+
+	// Act
+	e, err := TrainBoosted(samples, func(o *BoostOptions) {
+		o.Rounds = 30
+		o.LearningRate = 0.5
+		o.Link = LogisticLink
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	for _, s := range samples {
+		p, err := e.Predict(s.Request)
+		assert.NoError(t, err)
+		assert.True(t, p > 0 && p < 1)
+		assert.Equal(t, s.Label, p > 0.5, "the model should learn the training samples")
+	}
+}
+
+func TestEnsemble_Json(t *testing.T) {
+	// Arrange
+	e, err := TrainBoosted(deliverySamples(), func(o *BoostOptions) {
+		o.Rounds = 5
+	})
+	assert.NoError(t, err)
+
+	// Act
+	b, err := json.Marshal(e)
+	assert.NoError(t, err)
+	loaded, err := LoadEnsemble(b)
+	assert.NoError(t, err)
+
+	// Assert
+	request := map[string]interface{}{"distance": 12.0, "city": "paris"}
+	expected, _ := e.Predict(request)
+	actual, err := loaded.Predict(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, IdentityLink, loaded.Link)
+}
+
+func TestTrainBoosted_Errors(t *testing.T) {
+	_, err := TrainBoosted(nil)
+	assert.Equal(t, ErrNoSample, err)
+
+	_, err = TrainBoosted([]Sample{{Label: 3.0}}, func(o *BoostOptions) { o.Link = LogisticLink })
+	assert.Equal(t, ErrBadLabel, err)
+
+	_, err = TrainBoosted([]Sample{{Label: 3.0}}, func(o *BoostOptions) { o.Link = "probit" })
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package dtree
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Builder builds a tree in code, giving the ids of the nodes. Its methods return the Builder to be
+// chained, the first error is returned by Build:
+//
+//	tree, err := dtree.New("root").
+//		When("sayHello", "eq", false).Then("Goodbye", nil).
+//		When("sayHello", "eq", true).
+//			When("gender", "eq", "F").Then("Hello Miss", nil).
+//			Fallback().Then("Hello", nil).
+//		End().
+//		Build()
+type Builder struct {
+	nodes []Tree
+	// current is the index of the node on which the next nodes are added
+	current int
+	// children counts the children of each node, to keep the order in which they are added
+	children map[int]int
+	config   *TreeOptions
+	err      error
+}
+
+// New starts a tree which root has the name. The options (custom operators) are used to validate
+// the conditions.
+func New(name string, options ...func(t *TreeOptions)) *Builder {
+	return &Builder{
+		nodes:    []Tree{{ID: 1, Name: name}},
+		children: make(map[int]int),
+		config:   newTreeOptions(&TreeOptions{}, options...),
+	}
+}
+
+// add adds a child to the current node and returns its index. The children are ordered as added.
+func (b *Builder) add(node Tree) int {
+	parent := b.nodes[b.current].ID
+	b.children[parent]++
+
+	node.ID = len(b.nodes) + 1
+	node.ParentID = parent
+	node.Order = b.children[parent]
+	b.nodes = append(b.nodes, node)
+
+	return len(b.nodes) - 1
+}
+
+// fail keeps the first error
+func (b *Builder) fail(format string, args ...interface{}) *Builder {
+	if b.err == nil {
+		b.err = fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidTree}, args...)...)
+	}
+	return b
+}
+
+// When adds a node comparing the key with the value, the next nodes are added under it until
+// Then or End. The integers are converted to float64, the only numbers compared.
+func (b *Builder) When(key string, operator string, value interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	if _, ok := b.config.Operators[operator]; !ok && !isExistingOperator(operator) {
+		return b.fail("When(%q, %q, %v): %v", key, operator, value, ErrOperator)
+	}
+	if key == "" {
+		return b.fail("When(%q, %q, %v): no key", key, operator, value)
+	}
+
+	b.current = b.add(Tree{Key: key, Operator: operator, Value: numberValue(value)})
+	return b
+}
+
+// Fallback adds a node selected when no previous sibling matches, the next nodes are added under it
+// until Then or End. It is always evaluated the last.
+func (b *Builder) Fallback() *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	parent := b.nodes[b.current].ID
+	for _, n := range b.nodes {
+		if n.ParentID == parent && isFallback(&n) {
+			return b.fail("node %d has several fallbacks", parent)
+		}
+	}
+
+	b.current = b.add(Tree{Value: FallbackType})
+	return b
+}
+
+// Then adds the leaf selected by the current node, then ends it (see End)
+func (b *Builder) Then(name string, content interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	b.add(Tree{Name: name, Content: content})
+	if b.current == 0 {
+		return b
+	}
+	return b.End()
+}
+
+// End ends the current node, the next nodes are added to its parent
+func (b *Builder) End() *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	parent := b.nodes[b.current].ParentID
+	if parent == 0 {
+		return b.fail("End called on the root")
+	}
+
+	b.current = parent - 1
+	return b
+}
+
+// Order sets the order of the current node among its siblings (by default, the order in which they were added)
+func (b *Builder) Order(order int) *Builder {
+	b.nodes[b.current].Order = order
+	return b
+}
+
+// Name sets the name of the current node
+func (b *Builder) Name(name string) *Builder {
+	b.nodes[b.current].Name = name
+	return b
+}
+
+// Content sets the content of the current node
+func (b *Builder) Content(content interface{}) *Builder {
+	b.nodes[b.current].Content = content
+	return b
+}
+
+// Headers sets the headers of the current node
+func (b *Builder) Headers(headers map[string]interface{}) *Builder {
+	b.nodes[b.current].Headers = headers
+	return b
+}
+
+// Build returns the tree, or the first error met while building it
+func (b *Builder) Build() (*Tree, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	nodes := make([]Tree, len(b.nodes))
+	copy(nodes, b.nodes)
+
+	return CreateTree(nodes), nil
+}
+
+// MustBuild returns the tree, it panics if an error was met while building it
+func (b *Builder) MustBuild() *Tree {
+	t, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// numberValue converts the integers (also in slices) to float64, and the slices to []interface{}
+func numberValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32:
+		return v.Float()
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = numberValue(v.Index(i).Interface())
+		}
+		return values
+	}
+
+	return value
+}
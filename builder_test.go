@@ -0,0 +1,81 @@
+package dtree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	// Act
+	tr, err := New("root").
+		When("sayHello", "eq", false).Then("Goodbye", nil).
+		When("sayHello", "eq", true).
+		When("gender", "eq", "F").Then("Hello Miss", nil).
+		Fallback().Then("Hello", nil).
+		When("gender", "eq", "M").
+		When("age", "gt", 60).Then("Hello Sir", map[string]interface{}{"greeting": "sir"}).
+		When("age", "lte", 60).Then("Hello dude", nil).
+		End().
+		End().
+		When("country", "eq", []string{"FR", "BE"}).Order(0).Then("Bonjour", nil).
+		Build()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "root", tr.Name)
+	assert.Equal(t, []string{"country", "sayHello", "sayHello"}, []string{tr.GetChild()[0].Key, tr.GetChild()[1].Key, tr.GetChild()[2].Key})
+	assert.Equal(t, []interface{}{"FR", "BE"}, tr.GetChild()[0].Value)
+	assert.Equal(t, 60.0, tr.FindByID(10).Value, "the integers are converted to float64")
+
+	tests := []struct {
+		request  map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"sayHello": false}, "Goodbye"},
+		{map[string]interface{}{"sayHello": true, "gender": "F"}, "Hello Miss"},
+		{map[string]interface{}{"sayHello": true, "gender": "M", "age": 70.0}, "Hello Sir"},
+		{map[string]interface{}{"sayHello": true, "gender": "M", "age": 30.0}, "Hello dude"},
+		{map[string]interface{}{"sayHello": true}, "Hello"},
+		{map[string]interface{}{"country": "BE"}, "Bonjour"},
+	}
+	for _, test := range tests {
+		node, err := tr.Resolve(test.request)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, node.Name, "%v", test.request)
+	}
+
+	ids := map[int]bool{}
+	for _, n := range tr.Flatten() {
+		assert.False(t, ids[n.ID], "the ids are unique")
+		ids[n.ID] = true
+	}
+	assert.NoError(t, Validate(tr.Flatten()))
+}
+
+func TestBuilder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+	}{
+		{"unknown operator", New("root").When("age", "between", 3).Then("x", nil)},
+		{"no key", New("root").When("", "eq", 3)},
+		{"end on the root", New("root").When("age", "gt", 3).End().End()},
+		{"several fallbacks", New("root").Fallback().Then("a", nil).Fallback().Then("b", nil)},
+	}
+	for _, test := range tests {
+		_, err := test.builder.Build()
+
+		assert.True(t, errors.Is(err, ErrInvalidTree), test.name)
+	}
+
+	assert.Panics(t, func() {
+		New("root").When("", "eq", 3).MustBuild()
+	})
+	assert.NotPanics(t, func() {
+		New("root", func(t *TreeOptions) {
+			t.Operators = map[string]Operator{"between": nil}
+		}).When("age", "between", []int{1, 3}).MustBuild()
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/tkanos/go-dtree"
+)
+
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "write the changes as json")
+	draw := fs.Bool("draw", false, "draw the new tree with the changes highlighted")
+	match := fs.String("match", dtree.MatchAuto, "how the nodes are matched: id, path, or auto (the one giving the fewest changes)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree diff [-json] [-draw] [-match auto|id|path] old.json new.json")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Compares two versions of a tree: added, removed and moved branches, changed conditions,")
+		fmt.Fprintln(stderr, "values, names, contents, headers and orders. The nodes are matched by id, or by the conditions")
+		fmt.Fprintln(stderr, "leading to them when the ids changed.")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 0 no change, 1 the trees are different, 2 bad usage or tree.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 2 || (*match != dtree.MatchAuto && *match != dtree.MatchByID && *match != dtree.MatchByPath) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	trees := make([]*dtree.Tree, 2)
+	for i, path := range fs.Args() {
+		t, err := loadTreeFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: unable to load tree %s: %v\n", path, err)
+			return exitUsage
+		}
+		trees[i] = t
+	}
+
+	d := dtree.Diff(trees[0], trees[1], func(o *dtree.DiffOptions) {
+		o.Match = *match
+	})
+
+	code := exitOK
+	if len(d.Changes) > 0 {
+		code = exitError
+	}
+
+	switch {
+	case *asJSON:
+		b, err := json.Marshal(d)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintln(stdout, string(b))
+	case *draw:
+		fmt.Fprint(stdout, d.Draw())
+	default:
+		fmt.Fprint(stdout, d)
+	}
+
+	return code
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"diff", "testdata/tree.json", "testdata/tree.v2.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitError, code, stderr.String())
+	assert.Contains(t, stdout.String(), "2 changes (matched by id)")
+	assert.Contains(t, stdout.String(), "~ 5 age gt 65: value 60 -> 65")
+	assert.Contains(t, stdout.String(), "+ 9 Bye: added under 3 (sayHello eq false) (1 nodes)")
+}
+
+func TestDiff_Draw_And_JSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"diff", "-draw", "testdata/tree.json", "testdata/tree.v2.json"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitError, code, stderr.String())
+	assert.Contains(t, stdout.String(), "[+] Bye")
+
+	stdout.Reset()
+	code = run([]string{"diff", "-json", "testdata/tree.json", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitOK, code, stderr.String())
+	assert.Equal(t, `{"match":"id","changes":[]}`+"\n", stdout.String())
+}
+
+func TestDiff_Bad_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, run([]string{"diff", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"diff", "-match", "name", "testdata/tree.json", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"diff", "testdata/tree.json", "testdata/missing.json"}, strings.NewReader(""), &stdout, &stderr))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/tkanos/go-dtree"
+)
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "write the issues as json")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree lint [-json] tree.json...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Looks for logical problems on the trees (unreachable nodes, numeric gaps without fallback,")
+		fmt.Fprintln(stderr, "several fallbacks, percent over 100, nodes after a fallback, keys used with different types).")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 0 no issue, 1 issues were found, 2 bad usage or tree.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		t, err := loadTreeFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: unable to load tree %s: %v\n", path, err)
+			return exitUsage
+		}
+
+		issues := dtree.Lint(t)
+		if len(issues) > 0 {
+			code = exitError
+		}
+
+		if *asJSON {
+			if issues == nil {
+				issues = []dtree.LintIssue{}
+			}
+			b, err := json.Marshal(struct {
+				File   string            `json:"file"`
+				Issues []dtree.LintIssue `json:"issues"`
+			}{path, issues})
+			if err != nil {
+				fmt.Fprintf(stderr, "dtree: %v\n", err)
+				return exitError
+			}
+			fmt.Fprintln(stdout, string(b))
+			continue
+		}
+
+		for _, issue := range issues {
+			fmt.Fprintf(stdout, "%s: %s\n", path, issue)
+		}
+	}
+
+	return code
+}
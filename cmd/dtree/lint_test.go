@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint_Issues(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"lint", "testdata/lint.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stdout.String(), "testdata/lint.json: unreachable [2,3]")
+	assert.Contains(t, stdout.String(), "testdata/lint.json: range-gap [2,3] age lte 18")
+}
+
+func TestLint_No_Issue(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"lint", "-json", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code, stdout.String())
+	assert.Equal(t, `{"file":"testdata/tree.json","issues":[]}`+"\n", stdout.String())
+}
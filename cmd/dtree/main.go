@@ -0,0 +1,106 @@
+// Command dtree is a command line tool to work with dtree decision trees.
+//
+// Usage:
+//
+//	dtree <command> [arguments]
+//
+// The commands are:
+//
+//	resolve    resolve one json request or a ndjson stream against a tree
+//	lint       look for logical problems on tree files
+//	test       run the test suites of trees
+//	serve      host trees over http
+//	diff       compare two versions of a tree
+//	replay     compare the decisions of two versions of a tree on recorded requests
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/tkanos/go-dtree"
+)
+
+// exit codes returned by the commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+type command struct {
+	name  string
+	short string
+	run   func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "resolve", short: "resolve one json request or a ndjson stream against a tree", run: runResolve},
+	{name: "lint", short: "look for logical problems on tree files", run: runLint},
+	{name: "test", short: "run the test suites of trees", run: runTest},
+	{name: "serve", short: "host trees over http", run: runServe},
+	{name: "diff", short: "compare two versions of a tree", run: runDiff},
+	{name: "replay", short: "compare the decisions of two versions of a tree on recorded requests", run: runReplay},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return exitUsage
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "dtree: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "	dtree <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The commands are:")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "	%-10s %s\n", c.name, c.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Use \"dtree <command> -h\" for more information about a command.")
+}
+
+// loadTreeFile reads, validates and builds the tree stored on path
+func loadTreeFile(path string) (*dtree.Tree, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []dtree.Tree
+	if err := json.Unmarshal(b, &nodes); err != nil {
+		return nil, err
+	}
+	// CreateTree panics on unknown parents, and the commands on a tree without root
+	if err := dtree.Validate(nodes); err != nil {
+		return nil, err
+	}
+
+	t := dtree.CreateTree(nodes)
+	if t == nil {
+		return nil, errors.New("the tree has no root")
+	}
+	return t, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkanos/go-dtree"
+)
+
+func runReplay(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "-", "file containing a ndjson stream of recorded requests (- for stdin)")
+	examples := fs.Int("examples", 3, "number of example requests written for each change")
+	match := fs.String("match", dtree.MatchAuto, "how the nodes are matched: id, path, or auto (see dtree diff)")
+	asJSON := fs.Bool("json", false, "write the report as json")
+	stopOnError := fs.Bool("stop-on-error", false, "set StopIfConvertingError, a node that cannot be compared stops the resolution")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree replay [-input requests.ndjson] [-examples 3] [-match auto|id|path] [-json] [-stop-on-error] old.json new.json")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Resolves the recorded requests against both versions of the tree, and writes how many decisions")
+		fmt.Fprintln(stderr, "change, grouped by the old and the new selected nodes, with example requests and both decision paths.")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 0 no decision changes, 1 some decisions change, 2 bad usage, tree or request.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 2 || (*match != dtree.MatchAuto && *match != dtree.MatchByID && *match != dtree.MatchByPath) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	trees := make([]*dtree.Tree, 2)
+	for i, path := range fs.Args() {
+		t, err := loadTreeFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: unable to load tree %s: %v\n", path, err)
+			return exitUsage
+		}
+		trees[i] = t
+	}
+
+	r := stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var requests []map[string]interface{}
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var request map[string]interface{}
+		err := decoder.Decode(&request)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: request %d: %v\n", line, err)
+			return exitUsage
+		}
+		requests = append(requests, request)
+	}
+
+	report := dtree.Replay(trees[0], trees[1], requests, func(o *dtree.ReplayOptions) {
+		o.Examples = *examples
+		o.Match = *match
+		o.TreeOptions = []func(t *dtree.TreeOptions){func(t *dtree.TreeOptions) {
+			t.StopIfConvertingError = *stopOnError
+		}}
+	})
+
+	if *asJSON {
+		b, err := json.Marshal(report)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintln(stdout, string(b))
+	} else {
+		fmt.Fprint(stdout, report)
+	}
+
+	if report.Changed > 0 {
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkanos/go-dtree"
+)
+
+func TestReplay(t *testing.T) {
+	// Arrange
+	stdin := strings.NewReader(`{"sayHello": true, "age": 62}
+{"sayHello": true, "age": 70}
+{"sayHello": false}
+`)
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"replay", "testdata/tree.json", "testdata/tree.v2.json"}, stdin, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitError, code, stderr.String())
+	assert.Contains(t, stdout.String(), "requests: 3, changed: 1 (33.3%)")
+	assert.Contains(t, stdout.String(), "1: 6 (Hello Sir) -> 8 (Hello)")
+	assert.Contains(t, stdout.String(), "old path [2 5 6]")
+	assert.Contains(t, stdout.String(), "new path [2 7 8]")
+}
+
+func TestReplay_JSON(t *testing.T) {
+	// Arrange
+	stdin := strings.NewReader(`{"sayHello": true, "age": 70}`)
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"replay", "-json", "testdata/tree.json", "testdata/tree.json"}, stdin, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code, stderr.String())
+	var report dtree.ReplayReport
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.Equal(t, 1, report.Requests)
+	assert.Equal(t, 0, report.Changed)
+}
+
+func TestReplay_Bad_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, run([]string{"replay", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"replay", "testdata/tree.json", "testdata/tree.json"}, strings.NewReader("not a json"), &stdout, &stderr))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkanos/go-dtree"
+)
+
+// resolveResult is the line written for each resolved request
+type resolveResult struct {
+	ID      int         `json:"id"`
+	Name    string      `json:"name"`
+	Content interface{} `json:"content"`
+	Path    []string    `json:"path,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+func runResolve(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	treePath := fs.String("tree", "", "path of the json tree file (required)")
+	input := fs.String("input", "-", "file containing one json request or a ndjson stream of requests (- for stdin)")
+	withPath := fs.Bool("path", false, "write the decision path of each request")
+	stopOnError := fs.Bool("stop-on-error", false, "set StopIfConvertingError, a node that cannot be compared stops the resolution")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree resolve -tree tree.json [-input requests.ndjson] [-path] [-stop-on-error]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Resolves every json request read from the input and writes one json line per request")
+		fmt.Fprintln(stderr, "with the id, name and content of the selected node.")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 0 all requests resolved, 1 at least one request failed, 2 bad usage or tree.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *treePath == "" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	t, err := loadTreeFile(*treePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "dtree: unable to load tree %s: %v\n", *treePath, err)
+		return exitUsage
+	}
+
+	r := stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		r = f
+	}
+
+	options := func(o *dtree.TreeOptions) {
+		o.StopIfConvertingError = *stopOnError
+	}
+
+	return resolveStream(t, r, stdout, stderr, *withPath, options)
+}
+
+// resolveStream resolves every json value read on r (a single object or a ndjson stream)
+func resolveStream(t *dtree.Tree, r io.Reader, stdout, stderr io.Writer, withPath bool, options func(o *dtree.TreeOptions)) int {
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+
+	encoder := json.NewEncoder(w)
+	decoder := json.NewDecoder(r)
+	code := exitOK
+
+	for line := 1; ; line++ {
+		var request map[string]interface{}
+		err := decoder.Decode(&request)
+		if err == io.EOF {
+			return code
+		}
+		if err != nil {
+			// the decoder cannot resynchronize after a syntax error
+			fmt.Fprintf(stderr, "dtree: request %d: %v\n", line, err)
+			return exitError
+		}
+
+		node, ctx, err := t.ResolveWithContext(context.Background(), request, options)
+		result := resolveResult{}
+		if node != nil {
+			result.ID = node.ID
+			result.Name = node.Name
+			result.Content = node.Content
+		}
+		if withPath {
+			result.Path = dtree.GetNodePathFromContext(ctx)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			code = exitError
+		}
+
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitError
+		}
+	}
+}
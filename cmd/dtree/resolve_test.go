@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve_NDJSON(t *testing.T) {
+	// Arrange
+	stdin := strings.NewReader(`{"sayHello": true, "age": 70}
+{"sayHello": false}
+{"sayHello": true, "age": 30}
+`)
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"resolve", "-tree", "testdata/tree.json", "-path"}, stdin, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code, stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		var first resolveResult
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		assert.Equal(t, 6, first.ID)
+		assert.Equal(t, "Hello Sir", first.Name)
+		assert.Equal(t, map[string]interface{}{"greeting": "sir"}, first.Content)
+		assert.Equal(t, []string{"2 : sayHello true eq true", "5 : age 70 gt 60", "6 :  <nil>  <nil>"}, first.Path)
+
+		assert.Contains(t, lines[1], `"name":"Goodbye"`)
+		assert.Contains(t, lines[2], `"name":"Hello"`)
+	}
+}
+
+func TestResolve_Single_Request_With_Error(t *testing.T) {
+	// Arrange
+	stdin := strings.NewReader(`{
+		"sayHello": "yes"
+	}`)
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"resolve", "-tree", "testdata/tree.json", "-stop-on-error"}, stdin, &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stdout.String(), `"error":"types are different"`)
+}
+
+func TestResolve_Bad_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, run([]string{"resolve"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"resolve", "-tree", "testdata/missing.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"unknown"}, strings.NewReader(""), &stdout, &stderr))
+}
+
+func TestResolve_Malformed_Stream(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"resolve", "-tree", "testdata/tree.json"}, strings.NewReader(`{"sayHello": false} not a json`), &stdout, &stderr)
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stdout.String(), "Goodbye")
+	assert.Contains(t, stderr.String(), "request 2")
+}
+
+func TestResolve_Invalid_Tree(t *testing.T) {
+	// Arrange
+	dir, err := ioutil.TempDir("", "dtree")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	trees := map[string]string{
+		"empty":          `[]`,
+		"no root":        `[{"id": 1, "parent_id": 2}, {"id": 2, "parent_id": 1}]`,
+		"unknown parent": `[{"id": 1}, {"id": 2, "parent_id": 3}]`,
+	}
+
+	for name, tree := range trees {
+		path := filepath.Join(dir, strings.Replace(name, " ", "_", -1)+".json")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(tree), 0644))
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"resolve", "-tree", path}, strings.NewReader(`{"sayHello": true}`), &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code, name)
+		assert.Contains(t, stderr.String(), "invalid tree", name)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/tkanos/go-dtree"
+	"github.com/tkanos/go-dtree/handler"
+)
+
+// listenAndServe is replaced by the tests
+var listenAndServe = http.ListenAndServe
+
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	admin := fs.Bool("admin", false, "enable the endpoints editing the trees (see the handler package)")
+	stopOnError := fs.Bool("stop-on-error", false, "set StopIfConvertingError, a node that cannot be compared stops the resolution")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree serve [-addr :8080] [-admin] [-stop-on-error] [name=]tree.json...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Hosts the trees over http, named after their file without extension unless a name is given:")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "	GET  /health                 the status of the service")
+		fmt.Fprintln(stderr, "	GET  /trees                  the names and sizes of the hosted trees")
+		fmt.Fprintln(stderr, "	POST /trees/{name}/resolve   resolve the json request of the body (?trace=true to get the decision path)")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 1 the server stopped on an error, 2 bad usage or tree.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	trees := make(map[string]*dtree.Tree, fs.NArg())
+	for _, arg := range fs.Args() {
+		name, path := treeName(arg)
+		if _, ok := trees[name]; ok {
+			fmt.Fprintf(stderr, "dtree: several trees are named %q\n", name)
+			return exitUsage
+		}
+
+		t, err := loadTreeFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: unable to load tree %s: %v\n", path, err)
+			return exitUsage
+		}
+		trees[name] = t
+	}
+
+	s := handler.New(trees, func(o *handler.Options) {
+		o.Admin = *admin
+		o.TreeOptions = []func(t *dtree.TreeOptions){func(t *dtree.TreeOptions) {
+			t.StopIfConvertingError = *stopOnError
+		}}
+	})
+
+	fmt.Fprintf(stdout, "dtree: serving %d trees on %s\n", len(trees), *addr)
+	if err := listenAndServe(*addr, s); err != nil {
+		fmt.Fprintf(stderr, "dtree: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+// treeName splits a "name=path" argument, the name of a single path is its file name without extension
+func treeName(arg string) (string, string) {
+	if i := strings.Index(arg, "="); i > 0 {
+		return arg[:i], arg[i+1:]
+	}
+
+	base := filepath.Base(arg)
+	return strings.TrimSuffix(base, filepath.Ext(base)), arg
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	var served http.Handler
+	listenAndServe = func(addr string, h http.Handler) error {
+		assert.Equal(t, ":9000", addr)
+		served = h
+		return nil
+	}
+	defer func() { listenAndServe = http.ListenAndServe }()
+
+	// Act
+	code := run([]string{"serve", "-addr", ":9000", "-admin", "testdata/tree.json", "other=testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitOK, code, stderr.String())
+	if assert.NotNil(t, served) {
+		w := httptest.NewRecorder()
+		served.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/trees/other/resolve", strings.NewReader(`{"sayHello": false}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"Goodbye"`)
+
+		w = httptest.NewRecorder()
+		served.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trees", nil))
+		assert.Contains(t, w.Body.String(), `"name":"tree"`)
+
+		w = httptest.NewRecorder()
+		served.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/trees/tree/nodes/4", nil))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	listenAndServe = func(addr string, h http.Handler) error {
+		return errors.New("address already in use")
+	}
+	defer func() { listenAndServe = http.ListenAndServe }()
+
+	assert.Equal(t, exitUsage, run([]string{"serve"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"serve", "testdata/missing.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitUsage, run([]string{"serve", "testdata/tree.json", "tree=testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, exitError, run([]string{"serve", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tkanos/go-dtree"
+)
+
+func runTest(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	verbose := fs.Bool("v", false, "also write the passed cases")
+	stopOnError := fs.Bool("stop-on-error", false, "set StopIfConvertingError, a node that cannot be compared stops the resolution")
+	cover := fs.Bool("cover", false, "write the node coverage of the suites")
+	coverHTML := fs.String("coverhtml", "", "write the html coverage report of the last suite on this file")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dtree test [-v] [-stop-on-error] [-cover] [-coverhtml report.html] suite.json...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Runs the test suites: every case is resolved against the tree of the suite,")
+		fmt.Fprintln(stderr, "and the selected node is compared to the expected one.")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit codes: 0 all cases passed, 1 at least one case failed, 2 bad usage, suite or tree.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	options := func(o *dtree.TreeOptions) {
+		o.StopIfConvertingError = *stopOnError
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		s, t, err := dtree.LoadTestFile(path, options)
+		if err != nil {
+			fmt.Fprintf(stderr, "dtree: %v\n", err)
+			return exitUsage
+		}
+
+		coverage := dtree.NewCoverage(t)
+		report := s.Run(t, options, coverage.Option())
+
+		if *verbose {
+			for _, result := range report.Results {
+				if result.Passed() {
+					fmt.Fprintf(stdout, "--- PASS: %s\n", result.Case.Name)
+				}
+			}
+		}
+
+		fmt.Fprintf(stdout, "%s: %s", path, report)
+		if report.Failed > 0 {
+			code = exitError
+		}
+
+		if *cover {
+			fmt.Fprintf(stdout, "coverage:\n%s", coverage.Summary())
+		}
+
+		if *coverHTML != "" {
+			if err := writeCoverageHTML(*coverHTML, coverage); err != nil {
+				fmt.Fprintf(stderr, "dtree: %v\n", err)
+				return exitError
+			}
+		}
+	}
+
+	return code
+}
+
+func writeCoverageHTML(path string, coverage *dtree.Coverage) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := coverage.WriteHTML(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
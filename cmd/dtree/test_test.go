@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTest_Failure(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	code := run([]string{"test", "-v", "testdata/tree.test.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Equal(t, exitError, code, stderr.String())
+	assert.Contains(t, stdout.String(), "--- PASS: sir")
+	assert.Contains(t, stdout.String(), "--- FAIL: young")
+	assert.Contains(t, stdout.String(), "      2 sayHello eq true\n    - 5 age gt 60\n    - 6 Hello Sir\n    + 7 fallback\n    + 8 Hello\n")
+	assert.Contains(t, stdout.String(), "1 passed, 1 failed")
+}
+
+func TestTest_Bad_Suite(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, exitUsage, run([]string{"test", "testdata/tree.json"}, strings.NewReader(""), &stdout, &stderr))
+}
+
+func TestTest_Coverage(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	// Act
+	run([]string{"test", "-cover", "testdata/tree.test.json"}, strings.NewReader(""), &stdout, &stderr)
+
+	// Assert
+	assert.Contains(t, stdout.String(), "coverage:\nrequests: 2, failed: 0\n")
+	assert.Contains(t, stdout.String(), "never reached: 3 (sayHello eq false), 4 (Goodbye)")
+}
+
+func TestTest_Invalid_Tree(t *testing.T) {
+	// Arrange
+	dir, err := ioutil.TempDir("", "dtree")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	trees := map[string]string{
+		"empty":          `[]`,
+		"unknown_parent": `[{"id": 1}, {"id": 2, "parent_id": 3}]`,
+	}
+
+	for name, tree := range trees {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".json"), []byte(tree), 0644))
+		suite := filepath.Join(dir, name+".test.json")
+		assert.NoError(t, ioutil.WriteFile(suite, []byte(`{"tree": "`+name+`.json", "cases": [{"name": "any", "request": {}, "expected_id": 1}]}`), 0644))
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"test", suite}, strings.NewReader(""), &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code, name)
+		assert.Contains(t, stderr.String(), "invalid tree", name)
+	}
+}
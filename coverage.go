@@ -0,0 +1,282 @@
+package dtree
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+)
+
+// NodeCoverage counts what happened to a node during the recorded resolutions
+type NodeCoverage struct {
+	Node *Tree
+	// Evaluated is the number of times the node was compared to a request
+	Evaluated int
+	// Matched is the number of times the node was selected
+	Matched int
+	// Errors is the number of comparisons that returned an error
+	Errors int
+}
+
+// Reached returns true if the node was evaluated or selected at least once
+func (n NodeCoverage) Reached() bool {
+	return n.Evaluated > 0 || n.Matched > 0
+}
+
+// AlwaysErrored returns true if every comparison of the node returned an error
+func (n NodeCoverage) AlwaysErrored() bool {
+	return n.Evaluated > 0 && n.Errors == n.Evaluated
+}
+
+// Coverage records which nodes of a tree are evaluated and selected by the resolutions
+//
+//	c := dtree.NewCoverage(tree)
+//	for _, request := range requests {
+//		tree.Resolve(request, c.Option())
+//	}
+//	fmt.Println(c.Summary())
+type Coverage struct {
+	mu       sync.Mutex
+	tree     *Tree
+	nodes    []*NodeCoverage
+	byNode   map[*Tree]*NodeCoverage
+	requests int
+	failed   int
+}
+
+// NewCoverage creates a coverage collector for the tree
+func NewCoverage(t *Tree) *Coverage {
+	c := &Coverage{
+		tree:   t,
+		byNode: make(map[*Tree]*NodeCoverage),
+	}
+
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		nc := &NodeCoverage{Node: n}
+		c.nodes = append(c.nodes, nc)
+		c.byNode[n] = nc
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	if t != nil {
+		visit(t)
+	}
+
+	return c
+}
+
+// Option returns the option to give to Resolve, each resolution using it is recorded.
+// A request is counted once its resolution from the root of the tree ended, failed or not.
+func (c *Coverage) Option() func(t *TreeOptions) {
+	return func(o *TreeOptions) {
+		previousEvaluate := o.OnEvaluate
+		o.OnEvaluate = func(node *Tree, selected *Tree, err error) {
+			c.record(node, selected, err)
+			if previousEvaluate != nil {
+				previousEvaluate(node, selected, err)
+			}
+		}
+
+		previousResolve := o.OnResolve
+		o.OnResolve = func(root *Tree, selected *Tree, err error) {
+			c.recordRequest(root, err)
+			if previousResolve != nil {
+				previousResolve(root, selected, err)
+			}
+		}
+	}
+}
+
+func (c *Coverage) recordRequest(root *Tree, err error) {
+	if root != c.tree {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	if err != nil {
+		c.failed++
+	}
+	if nc, ok := c.byNode[root]; ok {
+		nc.Matched++
+	}
+}
+
+func (c *Coverage) record(node *Tree, selected *Tree, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if nc, ok := c.byNode[node]; ok {
+		nc.Evaluated++
+		if err != nil {
+			nc.Errors++
+		}
+	}
+
+	if nc, ok := c.byNode[selected]; ok && selected != nil {
+		nc.Matched++
+	}
+}
+
+// Requests returns the number of recorded resolutions
+func (c *Coverage) Requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requests
+}
+
+// Failed returns the number of recorded resolutions which returned an error
+func (c *Coverage) Failed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.failed
+}
+
+// Nodes returns the coverage of every node, in depth first order
+func (c *Coverage) Nodes() []NodeCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nodes := make([]NodeCoverage, len(c.nodes))
+	for i, nc := range c.nodes {
+		nodes[i] = *nc
+	}
+	return nodes
+}
+
+// Summary writes the number of requests and failed ones, the number of nodes evaluated and matched,
+// and lists the nodes never reached
+// and the ones for which every comparison failed
+func (c *Coverage) Summary() string {
+	nodes := c.Nodes()
+
+	var evaluated, matched int
+	var unreached, errored []string
+	for _, n := range nodes {
+		if n.Evaluated > 0 {
+			evaluated++
+		}
+		if n.Matched > 0 {
+			matched++
+		}
+		if !n.Reached() {
+			unreached = append(unreached, fmt.Sprintf("%d (%s)", n.Node.ID, n.Node.ValueToDraw()))
+		}
+		if n.AlwaysErrored() {
+			errored = append(errored, fmt.Sprintf("%d (%s)", n.Node.ID, n.Node.ValueToDraw()))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests: %d, failed: %d\n", c.Requests(), c.Failed())
+	fmt.Fprintf(&b, "nodes: %d, evaluated: %d (%s), matched: %d (%s)\n", len(nodes), evaluated, percentOf(evaluated, len(nodes)), matched, percentOf(matched, len(nodes)))
+	if len(unreached) > 0 {
+		fmt.Fprintf(&b, "never reached: %s\n", strings.Join(unreached, ", "))
+	}
+	if len(errored) > 0 {
+		fmt.Fprintf(&b, "always errored: %s\n", strings.Join(errored, ", "))
+	}
+
+	return b.String()
+}
+
+func percentOf(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// String draws the tree, with the number of evaluations, matches and errors under each node
+func (c *Coverage) String() string {
+	nodes := c.Nodes()
+	byNode := make(map[*Tree]NodeCoverage, len(nodes))
+	for _, n := range nodes {
+		byNode[n.Node] = n
+	}
+
+	return drawTree(c.tree, func(t *Tree) string {
+		n := byNode[t]
+		if !n.Reached() {
+			return t.ValueToDraw() + "\n(never reached)"
+		}
+		return fmt.Sprintf("%s\neval %d match %d err %d", t.ValueToDraw(), n.Evaluated, n.Matched, n.Errors)
+	})
+}
+
+type htmlCoverageNode struct {
+	NodeCoverage
+	Label    string
+	Class    string
+	Children []*htmlCoverageNode
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>dtree coverage</title>
+<style>
+body { font-family: sans-serif; }
+ul { list-style: none; border-left: 1px solid #ccc; margin-left: 0.5em; padding-left: 1em; }
+.node { display: inline-block; margin: 2px 0; padding: 2px 6px; border-radius: 3px; }
+.matched { background: #c8f0c8; }
+.evaluated { background: #fff3b0; }
+.unreached { background: #f6c6c6; }
+.errored { background: #f6c6c6; font-weight: bold; }
+.stats { color: #555; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>dtree coverage</h1>
+<pre>{{.Summary}}</pre>
+<ul>{{template "node" .Root}}</ul>
+</body>
+</html>
+{{define "node"}}<li><span class="node {{.Class}}">{{.Node.ID}} {{.Label}}</span> <span class="stats">eval {{.Evaluated}} match {{.Matched}} err {{.Errors}}</span>{{if .Children}}
+<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}</li>
+{{end}}`))
+
+// WriteHTML writes an html report of the coverage, nodes are colored according to what happened to them
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	nodes := c.Nodes()
+	byNode := make(map[*Tree]NodeCoverage, len(nodes))
+	for _, n := range nodes {
+		byNode[n.Node] = n
+	}
+
+	var build func(t *Tree) *htmlCoverageNode
+	build = func(t *Tree) *htmlCoverageNode {
+		n := &htmlCoverageNode{NodeCoverage: byNode[t], Label: t.ValueToDraw()}
+		switch {
+		case n.AlwaysErrored():
+			n.Class = "errored"
+		case n.Matched > 0:
+			n.Class = "matched"
+		case n.Evaluated > 0:
+			n.Class = "evaluated"
+		default:
+			n.Class = "unreached"
+		}
+		for _, child := range t.GetChild() {
+			n.Children = append(n.Children, build(child))
+		}
+		return n
+	}
+
+	if c.tree == nil {
+		return ErrNoNode
+	}
+
+	return coverageTemplate.Execute(w, struct {
+		Summary string
+		Root    *htmlCoverageNode
+	}{c.Summary(), build(c.tree)})
+}
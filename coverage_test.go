@@ -0,0 +1,104 @@
+package dtree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+	c := NewCoverage(tr)
+
+	// Act
+	tr.Resolve(map[string]interface{}{"isTest": true, "count": 15.0}, c.Option())
+	tr.Resolve(map[string]interface{}{"isTest": true, "count": "15"}, c.Option())
+
+	// Assert
+	assert.Equal(t, 2, c.Requests())
+
+	byID := make(map[int]NodeCoverage)
+	for _, n := range c.Nodes() {
+		byID[n.Node.ID] = n
+	}
+	assert.Equal(t, 2, byID[1].Matched, "the root is reached by every request")
+	assert.Equal(t, 2, byID[4].Matched)
+	assert.Equal(t, NodeCoverage{Node: byID[5].Node, Evaluated: 2, Matched: 1, Errors: 1}, byID[5])
+	assert.True(t, byID[7].AlwaysErrored(), "count lt 10 is only evaluated with a string")
+	assert.Equal(t, 1, byID[9].Matched)
+	assert.False(t, byID[3].Reached())
+	assert.False(t, byID[8].Reached())
+
+	summary := c.Summary()
+	assert.Contains(t, summary, "requests: 2, failed: 0\n")
+	assert.Contains(t, summary, "3 (Never Reach)")
+	assert.Contains(t, summary, "8 (FinalNode 1)")
+	assert.Contains(t, summary, "always errored: 7 (count lt 10)")
+
+	assert.Contains(t, c.String(), "(never reached)")
+	assert.Contains(t, c.String(), "eval 2 match 1 err 1")
+
+	var b bytes.Buffer
+	assert.NoError(t, c.WriteHTML(&b))
+	assert.Contains(t, b.String(), `<span class="node errored">7 count lt 10</span>`)
+	assert.Contains(t, b.String(), `<span class="node unreached">3 Never Reach</span>`)
+}
+
+func TestCoverage_Keeps_Existing_OnEvaluate(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+	c := NewCoverage(tr)
+	evaluated := 0
+	f := func(o *TreeOptions) {
+		o.OnEvaluate = func(node *Tree, selected *Tree, err error) {
+			evaluated++
+		}
+	}
+
+	// Act
+	tr.Resolve(map[string]interface{}{"isTest": false}, f, c.Option())
+
+	// Assert
+	total := 0
+	for _, n := range c.Nodes() {
+		total += n.Evaluated
+	}
+	assert.NotZero(t, evaluated)
+	assert.Equal(t, total, evaluated)
+	assert.Equal(t, 1, c.Requests())
+}
+
+func TestCoverage_Counts_Resolutions(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+	c := NewCoverage(tr)
+	option := c.Option()
+	stop := func(o *TreeOptions) {
+		o.StopIfConvertingError = true
+	}
+
+	// Act
+	// the option is applied twice for the request, as GenerateRequests does to read the options
+	option(&TreeOptions{})
+	tr.Resolve(map[string]interface{}{"isTest": true, "count": 15.0}, option)
+	_, err = tr.Resolve(map[string]interface{}{"isTest": true, "count": "15"}, option, stop)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 2, c.Requests())
+	assert.Equal(t, 1, c.Failed(), "the failed resolution is counted apart")
+	assert.Equal(t, 2, c.Nodes()[0].Matched)
+	for _, n := range c.Nodes() {
+		assert.True(t, n.Evaluated <= c.Requests(), "node %d is not evaluated more than once per request", n.Node.ID)
+	}
+	assert.Contains(t, c.Summary(), "requests: 2, failed: 1\n")
+
+	var b bytes.Buffer
+	assert.NoError(t, c.WriteHTML(&b))
+	assert.Contains(t, b.String(), "requests: 2, failed: 1")
+}
@@ -0,0 +1,386 @@
+package dtree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	drawer "github.com/m1gwings/treedrawer/tree"
+)
+
+// Kinds of NodeChange
+const (
+	DiffAdded        = "added"
+	DiffRemoved      = "removed"
+	DiffMoved        = "moved"
+	DiffCondition    = "condition"
+	DiffValue        = "value"
+	DiffContent      = "content"
+	DiffOrder        = "order"
+	DiffName         = "name"
+	DiffHeaders      = "headers"
+	DiffDefaultChild = "default-child"
+)
+
+// Matchings of the nodes of the two trees compared by Diff
+const (
+	// MatchAuto keeps the matching (by id or by path) giving the fewest changes
+	MatchAuto = "auto"
+	// MatchByID matches the nodes having the same id
+	MatchByID = "id"
+	// MatchByPath matches the nodes reached by the same conditions, for trees which ids changed
+	MatchByPath = "path"
+)
+
+// DiffOptions allow to configure Diff
+type DiffOptions struct {
+	// Match is the matching of the nodes (MatchAuto by default)
+	Match string
+}
+
+// NodeChange is a difference between two versions of a node
+type NodeChange struct {
+	Kind string `json:"kind"`
+	// OldID and NewID are the ids of the node in the old and the new trees (0 for an added or a removed node)
+	OldID int `json:"old_id,omitempty"`
+	NewID int `json:"new_id,omitempty"`
+	// Node describes the node (the new one, or the old one when it is removed)
+	Node string `json:"node"`
+	// Old and New are the values before and after the change, the parents for a move
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+	// Nodes is the number of nodes of an added or a removed branch
+	Nodes int `json:"nodes,omitempty"`
+}
+
+// TreeDiff is the list of the differences between two trees
+type TreeDiff struct {
+	Match   string       `json:"match"`
+	Changes []NodeChange `json:"changes"`
+
+	before, after *Tree
+	// matches maps the nodes of each tree to the ones of the other
+	matches map[*Tree]*Tree
+}
+
+// Diff compares two versions of a tree: the nodes are matched (see DiffOptions), then the added,
+// removed and moved branches and the changes of the matched nodes are listed, in the order of the
+// new tree
+func Diff(before, after *Tree, options ...func(o *DiffOptions)) *TreeDiff {
+	config := &DiffOptions{Match: MatchAuto}
+	for _, option := range options {
+		option(config)
+	}
+
+	switch config.Match {
+	case MatchByID:
+		return diffMatched(before, after, MatchByID, matchByID(before, after))
+	case MatchByPath:
+		return diffMatched(before, after, MatchByPath, matchByPath(before, after))
+	}
+
+	byID := diffMatched(before, after, MatchByID, matchByID(before, after))
+	byPath := diffMatched(before, after, MatchByPath, matchByPath(before, after))
+	if len(byPath.Changes) < len(byID.Changes) {
+		return byPath
+	}
+	return byID
+}
+
+// matchByID matches the nodes having the same id, the roots always match
+func matchByID(before, after *Tree) map[*Tree]*Tree {
+	matches := map[*Tree]*Tree{before: after, after: before}
+
+	ids := make(map[int]*Tree)
+	before.Walk(func(n *Tree) error {
+		if _, ok := ids[n.ID]; !ok && n != before {
+			ids[n.ID] = n
+		}
+		return nil
+	})
+
+	after.Walk(func(n *Tree) error {
+		if b, ok := ids[n.ID]; ok && n != after {
+			if _, matched := matches[b]; !matched {
+				matches[b] = n
+				matches[n] = b
+			}
+		}
+		return nil
+	})
+
+	return matches
+}
+
+// matchByPath matches the children of the matched nodes: the ones with the same condition, then the
+// ones on the same key, then the remaining ones without key, in their order. The unmatched branches
+// which are identical are matched too (moved branches).
+func matchByPath(before, after *Tree) map[*Tree]*Tree {
+	matches := make(map[*Tree]*Tree)
+	matchPath(before, after, matches)
+
+	removed := unmatchedBranches(before, matches)
+	for _, a := range unmatchedBranches(after, matches) {
+		signature := branchSignature(a)
+		for i, b := range removed {
+			if b != nil && branchSignature(b) == signature {
+				matchPath(b, a, matches)
+				removed[i] = nil
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+func matchPath(b, a *Tree, matches map[*Tree]*Tree) {
+	matches[b] = a
+	matches[a] = b
+
+	pair := func(same func(b, a *Tree) bool) {
+		for _, ac := range a.nodes {
+			if _, ok := matches[ac]; ok {
+				continue
+			}
+			for _, bc := range b.nodes {
+				if _, ok := matches[bc]; !ok && same(bc, ac) {
+					matchPath(bc, ac, matches)
+					break
+				}
+			}
+		}
+	}
+
+	pair(func(b, a *Tree) bool {
+		return conditionSignature(b) == conditionSignature(a)
+	})
+	pair(func(b, a *Tree) bool {
+		return b.Key != "" && b.Key == a.Key
+	})
+	pair(func(b, a *Tree) bool {
+		return b.Key == "" && a.Key == ""
+	})
+}
+
+// conditionSignature describes what selects the node, its name for a node without condition
+func conditionSignature(n *Tree) string {
+	if n.Key == "" && n.Operator == "" && !isFallback(n) {
+		return "name " + n.Name
+	}
+	return fmt.Sprintf("%s %s %s", n.Key, canonicalOperator(n.Operator), classKey(n.Value))
+}
+
+// branchSignature describes the conditions and the names of the node and its descendants
+func branchSignature(n *Tree) string {
+	var b strings.Builder
+	b.WriteString(conditionSignature(n) + " " + n.Name + " " + classKey(n.Content) + " (")
+	for _, child := range n.nodes {
+		b.WriteString(branchSignature(child))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// unmatchedBranches returns the unmatched nodes which parent is matched
+func unmatchedBranches(t *Tree, matches map[*Tree]*Tree) []*Tree {
+	var branches []*Tree
+	t.Walk(func(n *Tree) error {
+		if _, ok := matches[n]; !ok {
+			branches = append(branches, n)
+			return ErrSkipChildren
+		}
+		return nil
+	})
+	return branches
+}
+
+// branchSize returns the number of unmatched nodes of the branch
+func branchSize(t *Tree, matches map[*Tree]*Tree) int {
+	size := 0
+	t.Walk(func(n *Tree) error {
+		if _, ok := matches[n]; ok {
+			return ErrSkipChildren
+		}
+		size++
+		return nil
+	})
+	return size
+}
+
+// describeNode describes a node with its id
+func describeNode(n *Tree) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d (%s)", n.ID, n.ValueToDraw())
+}
+
+// describeDefault describes a default child, nil if there is none
+func describeDefault(n *Tree) interface{} {
+	if n == nil {
+		return nil
+	}
+	return describeNode(n)
+}
+
+// diffMatched lists the changes between the matched trees
+func diffMatched(before, after *Tree, match string, matches map[*Tree]*Tree) *TreeDiff {
+	d := &TreeDiff{Match: match, Changes: []NodeChange{}, before: before, after: after, matches: matches}
+
+	after.Walk(func(a *Tree) error {
+		b, ok := matches[a]
+		if !ok {
+			// only the first node of an added branch is listed, its matched descendants were moved in it
+			if _, parentMatched := matches[a.parent]; parentMatched {
+				d.Changes = append(d.Changes, NodeChange{Kind: DiffAdded, NewID: a.ID, Node: a.ValueToDraw(), New: describeNode(a.parent), Nodes: branchSize(a, matches)})
+			}
+			return nil
+		}
+
+		d.Changes = append(d.Changes, nodeChanges(b, a, matches)...)
+		for _, bc := range b.nodes {
+			if _, ok := matches[bc]; !ok {
+				d.Changes = append(d.Changes, NodeChange{Kind: DiffRemoved, OldID: bc.ID, Node: bc.ValueToDraw(), Old: describeNode(b), Nodes: branchSize(bc, matches)})
+			}
+		}
+		return nil
+	})
+
+	return d
+}
+
+// nodeChanges compares two matched nodes
+func nodeChanges(b, a *Tree, matches map[*Tree]*Tree) []NodeChange {
+	var changes []NodeChange
+	change := func(kind string, from, to interface{}) {
+		changes = append(changes, NodeChange{Kind: kind, OldID: b.ID, NewID: a.ID, Node: a.ValueToDraw(), Old: from, New: to})
+	}
+
+	if b.parent != nil && a.parent != nil && matches[b.parent] != a.parent {
+		change(DiffMoved, describeNode(b.parent), describeNode(a.parent))
+	}
+	if b.Key != a.Key || canonicalOperator(b.Operator) != canonicalOperator(a.Operator) {
+		change(DiffCondition, strings.TrimSpace(b.Key+" "+b.Operator), strings.TrimSpace(a.Key+" "+a.Operator))
+	}
+	if !reflect.DeepEqual(b.Value, a.Value) {
+		change(DiffValue, b.Value, a.Value)
+	}
+	if b.Name != a.Name {
+		change(DiffName, b.Name, a.Name)
+	}
+	if !reflect.DeepEqual(b.Content, a.Content) {
+		change(DiffContent, b.Content, a.Content)
+	}
+	if !reflect.DeepEqual(b.Headers, a.Headers) {
+		change(DiffHeaders, b.Headers, a.Headers)
+	}
+	if b.Order != a.Order {
+		change(DiffOrder, b.Order, a.Order)
+	}
+	// the default children are compared through the matching, their ids can differ
+	if bd, ad := b.defaultChild(), a.defaultChild(); (bd == nil) != (ad == nil) || (bd != nil && matches[bd] != ad) {
+		change(DiffDefaultChild, describeDefault(bd), describeDefault(ad))
+	}
+
+	return changes
+}
+
+// String writes one line per change
+func (c NodeChange) String() string {
+	id := fmt.Sprint(c.NewID)
+	switch {
+	case c.Kind == DiffRemoved:
+		id = fmt.Sprint(c.OldID)
+	case c.OldID != 0 && c.NewID != 0 && c.OldID != c.NewID:
+		id = fmt.Sprintf("%d (was %d)", c.NewID, c.OldID)
+	}
+
+	switch c.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s %s: added under %v (%d nodes)", id, c.Node, c.New, c.Nodes)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s %s: removed from %v (%d nodes)", id, c.Node, c.Old, c.Nodes)
+	case DiffMoved:
+		return fmt.Sprintf("> %s %s: moved from %v to %v", id, c.Node, c.Old, c.New)
+	}
+	return fmt.Sprintf("~ %s %s: %s %s -> %s", id, c.Node, c.Kind, classKey(c.Old), classKey(c.New))
+}
+
+// String writes the changes, one per line
+func (d *TreeDiff) String() string {
+	if len(d.Changes) == 0 {
+		return fmt.Sprintf("no change (matched by %s)\n", d.Match)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d changes (matched by %s)\n", len(d.Changes), d.Match)
+	for _, c := range d.Changes {
+		b.WriteString(c.String() + "\n")
+	}
+	return b.String()
+}
+
+// Draw draws the new tree with the removed branches, each node marked as [+] added, [-] removed,
+// [>] moved or [~] changed, with its changes
+func (d *TreeDiff) Draw() string {
+	byNode := make(map[int][]NodeChange)
+	for _, c := range d.Changes {
+		if c.Kind != DiffAdded && c.Kind != DiffRemoved {
+			byNode[c.NewID] = append(byNode[c.NewID], c)
+		}
+	}
+
+	label := func(a *Tree) string {
+		if _, ok := d.matches[a]; !ok {
+			return "[+] " + a.ValueToDraw()
+		}
+
+		changes := byNode[a.ID]
+		if len(changes) == 0 {
+			return a.ValueToDraw()
+		}
+
+		marker := "[~] "
+		lines := make([]string, 0, len(changes))
+		for _, c := range changes {
+			if c.Kind == DiffMoved {
+				marker = "[>] "
+				lines = append(lines, fmt.Sprintf("moved from %v", c.Old))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s %s -> %s", c.Kind, classKey(c.Old), classKey(c.New)))
+			}
+		}
+		return marker + a.ValueToDraw() + "\n" + strings.Join(lines, "\n")
+	}
+	var draw, drawRemoved func(t *Tree, dt *drawer.Tree)
+	draw = func(a *Tree, dt *drawer.Tree) {
+		dt.SetVal(drawer.NodeString(label(a)))
+		for _, child := range a.nodes {
+			draw(child, dt.AddChild(drawer.NodeString("")))
+		}
+
+		b, ok := d.matches[a]
+		if !ok {
+			return
+		}
+		for _, bc := range b.nodes {
+			if _, ok := d.matches[bc]; !ok {
+				drawRemoved(bc, dt.AddChild(drawer.NodeString("")))
+			}
+		}
+	}
+	// the matched nodes of a removed branch are drawn where they were moved
+	drawRemoved = func(b *Tree, dt *drawer.Tree) {
+		dt.SetVal(drawer.NodeString("[-] " + b.ValueToDraw()))
+		for _, child := range b.nodes {
+			if _, ok := d.matches[child]; !ok {
+				drawRemoved(child, dt.AddChild(drawer.NodeString("")))
+			}
+		}
+	}
+
+	dt := drawer.NewTree(drawer.NodeString(""))
+	draw(d.after, dt)
+	return dt.String()
+}
@@ -0,0 +1,138 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func changesOf(d *TreeDiff) map[string][]NodeChange {
+	byKind := make(map[string][]NodeChange)
+	for _, c := range d.Changes {
+		byKind[c.Kind] = append(byKind[c.Kind], c)
+	}
+	return byKind
+}
+
+func TestDiff_ByID(t *testing.T) {
+	// Arrange
+	before := loadHello(t)
+	after := before.Clone()
+	after.FindByID(10).Value = 65.0
+	after.FindByID(13).Content = "dude"
+	after.RemoveNode(after.FindByID(3))
+	after.FindByID(9).AddNode(&Tree{ID: 14, ParentID: 9, Key: "age", Operator: "gt", Value: 90.0, Order: -1})
+	after.FindByID(14).AddNode(&Tree{ID: 15, ParentID: 14, Name: "Hello old sir"})
+	after.MoveNode(after.FindByID(5))
+
+	// Act
+	d := Diff(before, after)
+
+	// Assert
+	assert.Equal(t, MatchByID, d.Match)
+	changes := changesOf(d)
+	assert.Equal(t, []NodeChange{{Kind: DiffValue, OldID: 10, NewID: 10, Node: "age gt 65", Old: 60.0, New: 65.0}}, changes[DiffValue])
+	assert.Equal(t, []NodeChange{{Kind: DiffContent, OldID: 13, NewID: 13, Node: "Hello dude", Old: nil, New: "dude"}}, changes[DiffContent])
+	assert.Equal(t, []NodeChange{{Kind: DiffRemoved, OldID: 3, Node: "sayHello eq false", Old: "1 (root)", Nodes: 2}}, changes[DiffRemoved])
+	assert.Equal(t, []NodeChange{{Kind: DiffAdded, NewID: 14, Node: "age gt 90", New: "9 (gender eq M)", Nodes: 2}}, changes[DiffAdded])
+	assert.Equal(t, []NodeChange{{Kind: DiffMoved, OldID: 5, NewID: 5, Node: "gender eq F", Old: "2 (sayHello eq true)", New: "1 (root)"}}, changes[DiffMoved])
+	assert.Len(t, d.Changes, 5)
+
+	assert.Contains(t, d.String(), "5 changes (matched by id)")
+	assert.Contains(t, d.String(), "+ 14 age gt 90: added under 9 (gender eq M) (2 nodes)")
+	assert.Contains(t, d.String(), "~ 10 age gt 65: value 60 -> 65")
+	drawing := d.Draw()
+	assert.Contains(t, drawing, "[+] age gt 90")
+	assert.Contains(t, drawing, "[+] Hello old sir")
+	assert.Contains(t, drawing, "[-] sayHello eq false")
+	assert.Contains(t, drawing, "[-] Goodbye")
+	assert.Contains(t, drawing, "[~] age gt 65")
+	assert.Contains(t, drawing, "[>] gender eq F")
+}
+
+func TestDiff_ByPath(t *testing.T) {
+	// Arrange
+	before := loadHello(t)
+	after := before.Clone(func(o *CloneOptions) {
+		o.FirstID = 100
+	})
+	after.FindByName("Hello Miss")[0].Name = "Hello Madam"
+
+	// Act
+	d := Diff(before, after)
+
+	// Assert
+	assert.Equal(t, MatchByPath, d.Match)
+	if assert.Len(t, d.Changes, 1) {
+		assert.Equal(t, DiffName, d.Changes[0].Kind)
+		assert.Equal(t, 6, d.Changes[0].OldID)
+		assert.Equal(t, "Hello Miss", d.Changes[0].Old)
+		assert.Contains(t, d.String(), "(was 6) Hello Madam: name Hello Miss -> Hello Madam")
+	}
+
+	byID := Diff(before, after, func(o *DiffOptions) {
+		o.Match = MatchByID
+	})
+	assert.Equal(t, MatchByID, byID.Match)
+	assert.True(t, len(byID.Changes) > 1)
+}
+
+func TestDiff_ByPath_Moved_Branch(t *testing.T) {
+	// Arrange
+	before := New("root").
+		When("a", "eq", "x").Then("X", nil).
+		When("b", "eq", "y").When("c", "eq", "z").Then("Z", nil).End().
+		MustBuild()
+	after := New("root").
+		When("a", "eq", "x").When("c", "eq", "z").Then("Z", nil).Then("X", nil).
+		When("b", "eq", "y").End().
+		MustBuild()
+
+	// Act
+	d := Diff(before, after, func(o *DiffOptions) {
+		o.Match = MatchByPath
+	})
+
+	// Assert
+	changes := changesOf(d)
+	if assert.Len(t, changes[DiffMoved], 1) {
+		assert.Equal(t, "c eq z", changes[DiffMoved][0].Node)
+		assert.Equal(t, "4 (b eq y)", changes[DiffMoved][0].Old)
+	}
+	assert.Empty(t, changes[DiffAdded])
+	assert.Empty(t, changes[DiffRemoved])
+}
+
+func TestDiff_Same(t *testing.T) {
+	before := loadHello(t)
+
+	d := Diff(before, CreateTree(before.Flatten()))
+
+	assert.Empty(t, d.Changes)
+	assert.Equal(t, "no change (matched by id)\n", d.String())
+}
+
+func TestDiff_DefaultChild(t *testing.T) {
+	// Arrange
+	before := loadHello(t)
+	before.FindByID(2).DefaultChild = 5
+	renumbered := before.Clone(func(o *CloneOptions) {
+		o.FirstID = 100
+	})
+	after := before.Clone()
+	after.FindByID(2).DefaultChild = 9
+	after.FindByID(9).DefaultChild = 12
+
+	// Act
+	d := Diff(before, after)
+
+	// Assert
+	assert.Equal(t, []NodeChange{
+		{Kind: DiffDefaultChild, OldID: 2, NewID: 2, Node: "sayHello eq true", Old: "5 (gender eq F)", New: "9 (gender eq M)"},
+		{Kind: DiffDefaultChild, OldID: 9, NewID: 9, Node: "gender eq M", Old: nil, New: "12 (age lte 60)"},
+	}, d.Changes)
+	assert.Contains(t, d.String(), "~ 9 gender eq M: default-child <nil> -> 12 (age lte 60)")
+	assert.Empty(t, Diff(before, renumbered, func(o *DiffOptions) {
+		o.Match = MatchByPath
+	}).Changes, "the default children are matched")
+}
@@ -0,0 +1,167 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ClassMetrics measures the predictions of one class
+type ClassMetrics struct {
+	Class string `json:"class"`
+	// Precision is the part of the samples predicted in the class which have its label
+	Precision float64 `json:"precision"`
+	// Recall is the part of the samples having the label of the class which are predicted in it
+	Recall float64 `json:"recall"`
+	// F1 is the harmonic mean of the precision and the recall
+	F1 float64 `json:"f1"`
+	// Support is the number of samples having the label of the class
+	Support int `json:"support"`
+}
+
+// Misclassified is a sample whose outcome is not its label
+type Misclassified struct {
+	// Index is the position of the sample in the dataset
+	Index   int                    `json:"index"`
+	Request map[string]interface{} `json:"request"`
+	Label   interface{}            `json:"label"`
+	Outcome interface{}            `json:"outcome"`
+	// Path are the ids of the nodes from the root (excluded) to the selected node
+	Path  []int  `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+// ClassificationReport measures the outcomes of a tree on labeled samples
+type ClassificationReport struct {
+	Count    int     `json:"count"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	// MacroF1 is the mean of the F1 of the classes
+	MacroF1 float64 `json:"macro_f1"`
+	// Classes are the labels and the outcomes (written with fmt.Sprint, json for the objects), sorted
+	Classes []string `json:"classes"`
+	// Matrix is the confusion matrix: Matrix[i][j] is the number of samples labeled Classes[i] whose outcome is Classes[j]
+	Matrix        [][]int         `json:"matrix"`
+	PerClass      []ClassMetrics  `json:"per_class"`
+	Misclassified []Misclassified `json:"misclassified"`
+}
+
+// classKey writes an outcome or a label as a class name
+func classKey(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// EvaluateClassification resolves every sample and compares the outcome of the selected node
+// (its Content, or its Name if it has no Content) to the label of the sample.
+// It works on trained trees and on hand-written ones, whose Content can be any json value.
+func EvaluateClassification(t *Tree, samples []Sample, options ...func(t *TreeOptions)) (*ClassificationReport, error) {
+	if t == nil {
+		return nil, ErrNoNode
+	}
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+
+	r := &ClassificationReport{Count: len(samples)}
+	labels := make([]string, len(samples))
+	outcomes := make([]string, len(samples))
+	known := make(map[string]bool)
+	for i, s := range samples {
+		node, err := t.Resolve(s.Request, options...)
+		outcome := Outcome(node)
+		labels[i], outcomes[i] = classKey(s.Label), classKey(outcome)
+		known[labels[i]], known[outcomes[i]] = true, true
+
+		if err == nil && sameOutcome(outcome, s.Label) {
+			r.Correct++
+			continue
+		}
+
+		m := Misclassified{Index: i, Request: s.Request, Label: s.Label, Outcome: outcome, Path: pathIDs(node)}
+		if err != nil {
+			m.Error = err.Error()
+		}
+		r.Misclassified = append(r.Misclassified, m)
+	}
+	r.Accuracy = float64(r.Correct) / float64(r.Count)
+
+	for c := range known {
+		r.Classes = append(r.Classes, c)
+	}
+	sort.Strings(r.Classes)
+	index := make(map[string]int, len(r.Classes))
+	for i, c := range r.Classes {
+		index[c] = i
+		r.Matrix = append(r.Matrix, make([]int, len(r.Classes)))
+	}
+	for i := range samples {
+		r.Matrix[index[labels[i]]][index[outcomes[i]]]++
+	}
+
+	for i, c := range r.Classes {
+		m := ClassMetrics{Class: c}
+		predicted := 0
+		for j := range r.Classes {
+			m.Support += r.Matrix[i][j]
+			predicted += r.Matrix[j][i]
+		}
+
+		truePositives := r.Matrix[i][i]
+		if predicted > 0 {
+			m.Precision = float64(truePositives) / float64(predicted)
+		}
+		if m.Support > 0 {
+			m.Recall = float64(truePositives) / float64(m.Support)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+
+		r.PerClass = append(r.PerClass, m)
+		r.MacroF1 += m.F1 / float64(len(r.Classes))
+	}
+
+	return r, nil
+}
+
+// String writes the accuracy, the metrics of each class and the confusion matrix
+// (a row per label, a column per outcome)
+func (r *ClassificationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "accuracy: %.4f (%d/%d), macro f1: %.4f\n\n", r.Accuracy, r.Correct, r.Count, r.MacroF1)
+
+	width := len("label \\ outcome")
+	for _, c := range r.Classes {
+		if len(c) > width {
+			width = len(c)
+		}
+	}
+
+	fmt.Fprintf(&b, "%-*s %9s %9s %9s %9s\n", width, "class", "precision", "recall", "f1", "support")
+	for _, m := range r.PerClass {
+		fmt.Fprintf(&b, "%-*s %9.4f %9.4f %9.4f %9d\n", width, m.Class, m.Precision, m.Recall, m.F1, m.Support)
+	}
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "%-*s", width, "label \\ outcome")
+	for _, c := range r.Classes {
+		fmt.Fprintf(&b, " %*s", len(c), c)
+	}
+	b.WriteString("\n")
+	for i, c := range r.Classes {
+		fmt.Fprintf(&b, "%-*s", width, c)
+		for j, o := range r.Classes {
+			fmt.Fprintf(&b, " %*d", len(o), r.Matrix[i][j])
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
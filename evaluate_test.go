@@ -0,0 +1,80 @@
+package dtree
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateClassification(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Key: "age", Operator: "gte", Value: 18.0, Order: 1, Content: "adult"},
+		{ID: 3, ParentID: 1, Key: "age", Operator: "lt", Value: 18.0, Order: 2, Content: "child"},
+	})
+	samples := []Sample{
+		{Request: map[string]interface{}{"age": 30.0}, Label: "adult"},
+		{Request: map[string]interface{}{"age": 20.0}, Label: "adult"},
+		{Request: map[string]interface{}{"age": 17.0}, Label: "adult"},
+		{Request: map[string]interface{}{"age": 10.0}, Label: "child"},
+		{Request: map[string]interface{}{"age": 19.0}, Label: "child"},
+	}
+
+	// Act
+	r, err := EvaluateClassification(tr, samples)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, r.Count)
+	assert.Equal(t, 3, r.Correct)
+	assert.Equal(t, 0.6, r.Accuracy)
+	assert.Equal(t, []string{"adult", "child"}, r.Classes)
+	assert.Equal(t, [][]int{{2, 1}, {1, 1}}, r.Matrix)
+
+	assert.Equal(t, ClassMetrics{Class: "adult", Precision: 2.0 / 3, Recall: 2.0 / 3, F1: 2.0 / 3, Support: 3}, r.PerClass[0])
+	assert.Equal(t, "child", r.PerClass[1].Class)
+	assert.Equal(t, 0.5, r.PerClass[1].Precision)
+	assert.Equal(t, 0.5, r.PerClass[1].Recall)
+
+	assert.Len(t, r.Misclassified, 2)
+	assert.Equal(t, 2, r.Misclassified[0].Index)
+	assert.Equal(t, "child", r.Misclassified[0].Outcome)
+	assert.Equal(t, []int{3}, r.Misclassified[0].Path)
+	assert.Contains(t, r.String(), "accuracy: 0.6000 (3/5)")
+}
+
+func TestEvaluateClassification_HandWrittenTree(t *testing.T) {
+	// Arrange
+	b, err := ioutil.ReadFile("testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := LoadTree(b)
+	assert.NoError(t, err)
+	samples := []Sample{
+		{Request: map[string]interface{}{"sayHello": false}, Label: "Goodbye"},
+		{Request: map[string]interface{}{"sayHello": true, "gender": "F"}, Label: "Hello Miss"},
+		{Request: map[string]interface{}{"sayHello": false}, Label: "Hello"},
+	}
+
+	// Act
+	r, err := EvaluateClassification(tr, samples)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Correct)
+	assert.Equal(t, []string{"Goodbye", "Hello", "Hello Miss"}, r.Classes)
+	assert.Equal(t, [][]int{{1, 0, 0}, {1, 0, 0}, {0, 0, 1}}, r.Matrix)
+	assert.Equal(t, []Misclassified{{
+		Index:   2,
+		Request: map[string]interface{}{"sayHello": false},
+		Label:   "Hello",
+		Outcome: "Goodbye",
+		Path:    []int{3, 4},
+	}}, r.Misclassified)
+}
+
+func TestEvaluateClassification_NoSample(t *testing.T) {
+	_, err := EvaluateClassification(&Tree{}, nil)
+	assert.Equal(t, ErrNoSample, err)
+}
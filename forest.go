@@ -0,0 +1,210 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Forest is a set of trees whose outcomes are combined (random forest)
+type Forest struct {
+	Trees []*Tree
+}
+
+// ForestOptions allow to configure the training of a forest
+type ForestOptions struct {
+	// Tree configures the training of each tree
+	Tree TrainOptions
+	// NumTrees is the number of trees (10 by default)
+	NumTrees int
+	// MaxFeatures is the number of keys randomly chosen for each tree (the square root of the number of keys by default)
+	MaxFeatures int
+	// SampleRatio is the size of each bootstrap sample, relative to the number of samples (1 by default)
+	SampleRatio float64
+	// Seed makes the training reproducible
+	Seed int64
+	// Regression trains regression trees (TrainRegression) instead of classification ones
+	Regression bool
+}
+
+// TrainForest trains a random forest: each tree is trained on a bootstrap sample
+// (samples drawn with replacement) with a random subset of the keys
+func TrainForest(samples []Sample, options ...func(o *ForestOptions)) (*Forest, error) {
+	config := &ForestOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+	if config.NumTrees <= 0 {
+		config.NumTrees = 10
+	}
+	if config.SampleRatio <= 0 {
+		config.SampleRatio = 1
+	}
+
+	kinds := featureKinds(samples, config.Tree.Features)
+	var features []string
+	for k := range kinds {
+		features = append(features, k)
+	}
+	sort.Strings(features)
+
+	maxFeatures := config.MaxFeatures
+	if maxFeatures <= 0 {
+		maxFeatures = int(math.Ceil(math.Sqrt(float64(len(features)))))
+	}
+	if maxFeatures > len(features) {
+		maxFeatures = len(features)
+	}
+
+	random := rand.New(rand.NewSource(config.Seed))
+	size := int(math.Ceil(config.SampleRatio * float64(len(samples))))
+
+	f := &Forest{}
+	for i := 0; i < config.NumTrees; i++ {
+		bootstrap := make([]Sample, size)
+		for j := range bootstrap {
+			bootstrap[j] = samples[random.Intn(len(samples))]
+		}
+
+		// without usable key, the options are kept as they are: no key is found and the tree is a leaf
+		treeOptions := config.Tree
+		if len(features) > 0 {
+			treeOptions.Features = nil
+			for _, k := range random.Perm(len(features))[:maxFeatures] {
+				treeOptions.Features = append(treeOptions.Features, features[k])
+			}
+		}
+
+		option := func(o *TrainOptions) {
+			*o = treeOptions
+		}
+
+		var t *Tree
+		var err error
+		if config.Regression {
+			t, err = TrainRegression(bootstrap, option)
+		} else {
+			t, err = Train(bootstrap, option)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		f.Trees = append(f.Trees, t)
+	}
+
+	return f, nil
+}
+
+// Outcomes resolves the request on every tree and returns the outcome (Content or Name) of each selected node
+func (f *Forest) Outcomes(request map[string]interface{}, options ...func(t *TreeOptions)) ([]interface{}, error) {
+	if len(f.Trees) == 0 {
+		return nil, ErrNoNode
+	}
+
+	outcomes := make([]interface{}, len(f.Trees))
+	for i, t := range f.Trees {
+		node, err := t.Resolve(request, options...)
+		if err != nil {
+			return nil, err
+		}
+		outcomes[i] = Outcome(node)
+	}
+
+	return outcomes, nil
+}
+
+// Probabilities returns the part of the trees voting for each outcome (written with fmt.Sprint)
+func (f *Forest) Probabilities(request map[string]interface{}, options ...func(t *TreeOptions)) (map[string]float64, error) {
+	outcomes, err := f.Outcomes(request, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	probabilities := make(map[string]float64)
+	for _, o := range outcomes {
+		probabilities[fmt.Sprint(o)] += 1 / float64(len(outcomes))
+	}
+
+	return probabilities, nil
+}
+
+// Vote returns the outcome selected by the majority of the trees
+func (f *Forest) Vote(request map[string]interface{}, options ...func(t *TreeOptions)) (interface{}, error) {
+	outcomes, err := f.Outcomes(request, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	var best interface{}
+	bestCount := 0
+	for _, o := range outcomes {
+		key := fmt.Sprint(o)
+		counts[key]++
+		if counts[key] > bestCount || (counts[key] == bestCount && key < fmt.Sprint(best)) {
+			best, bestCount = o, counts[key]
+		}
+	}
+
+	return best, nil
+}
+
+// Average returns the mean of the numbers predicted by the trees (see PredictionValue)
+func (f *Forest) Average(request map[string]interface{}, options ...func(t *TreeOptions)) (float64, error) {
+	if len(f.Trees) == 0 {
+		return 0, ErrNoNode
+	}
+
+	var sum float64
+	for _, t := range f.Trees {
+		v, err := t.Predict(request, options...)
+		if err != nil {
+			return 0, err
+		}
+		sum += v
+	}
+
+	return sum / float64(len(f.Trees)), nil
+}
+
+// MarshalJSON encodes the forest as a list of trees, each one on the json format read by LoadTree
+func (f *Forest) MarshalJSON() ([]byte, error) {
+	trees := make([][]Tree, len(f.Trees))
+	for i, t := range f.Trees {
+		trees[i] = t.Flatten()
+	}
+
+	return json.Marshal(trees)
+}
+
+// UnmarshalJSON decodes a list of trees
+func (f *Forest) UnmarshalJSON(b []byte) error {
+	var trees [][]Tree
+	if err := json.Unmarshal(b, &trees); err != nil {
+		return err
+	}
+
+	f.Trees = make([]*Tree, len(trees))
+	for i := range trees {
+		f.Trees[i] = CreateTree(trees[i])
+	}
+
+	return nil
+}
+
+// LoadForest gets a json (a list of trees) and builds the Forest related
+func LoadForest(jsonForest []byte) (*Forest, error) {
+	f := &Forest{}
+	if err := json.Unmarshal(jsonForest, f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,121 @@
+package dtree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainForest(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	f, err := TrainForest(samples, func(o *ForestOptions) {
+		o.NumTrees = 25
+		o.MaxFeatures = 2
+		o.Seed = 42
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, f.Trees, 25)
+
+	correct := 0
+	for _, s := range samples {
+		vote, err := f.Vote(s.Request)
+		assert.NoError(t, err)
+		if vote == s.Label {
+			correct++
+		}
+
+		p, err := f.Probabilities(s.Request)
+		assert.NoError(t, err)
+		assert.InDelta(t, 1, p["yes"]+p["no"], 1e-9)
+	}
+	assert.True(t, correct >= 11, "the forest should classify most of the training samples (%d/14)", correct)
+
+	again, err := TrainForest(samples, func(o *ForestOptions) {
+		o.NumTrees = 25
+		o.MaxFeatures = 2
+		o.Seed = 42
+	})
+	assert.NoError(t, err)
+	for i := range f.Trees {
+		assert.Equal(t, f.Trees[i].String(), again.Trees[i].String(), "the same seed should give the same forest")
+	}
+}
+
+func TestForest_Vote(t *testing.T) {
+	// Arrange
+	leaf := func(name string) *Tree {
+		return CreateTree([]Tree{{ID: 1, Name: name}})
+	}
+	f := &Forest{Trees: []*Tree{leaf("a"), leaf("b"), leaf("b")}}
+
+	// Act
+	vote, err := f.Vote(nil)
+	p, err2 := f.Probabilities(nil)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	assert.Equal(t, "b", vote)
+	assert.InDelta(t, 1.0/3, p["a"], 1e-9)
+	assert.InDelta(t, 2.0/3, p["b"], 1e-9)
+
+	_, err = (&Forest{}).Vote(nil)
+	assert.Equal(t, ErrNoNode, err)
+}
+
+func TestForest_Regression_Json(t *testing.T) {
+	// Arrange
+	samples := deliverySamples()
+	f, err := TrainForest(samples, func(o *ForestOptions) {
+		o.NumTrees = 5
+		o.MaxFeatures = 2
+		o.Regression = true
+	})
+	assert.NoError(t, err)
+
+	// Act
+	b, err := json.Marshal(f)
+	assert.NoError(t, err)
+	loaded, err := LoadForest(b)
+	assert.NoError(t, err)
+
+	// Assert
+	request := map[string]interface{}{"distance": 3.0, "city": "paris"}
+	expected, err := f.Average(request)
+	assert.NoError(t, err)
+	actual, err := loaded.Average(request)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.InDelta(t, 20, actual, 5)
+	assert.Equal(t, f.Trees[0].String(), loaded.Trees[0].String())
+
+	var raw [][]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &raw), "a forest is a list of trees on the LoadTree format")
+	assert.Len(t, raw, 5)
+}
+
+func TestTrainForest_Without_Feature(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	f, err := TrainForest(samples, func(o *ForestOptions) {
+		o.NumTrees = 3
+		o.Tree.Features = []string{"unknown"}
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	for _, tr := range f.Trees {
+		assert.Equal(t, 1, tr.Height(), "no key can be used, the root only has its outcome")
+	}
+	vote, err := f.Vote(map[string]interface{}{"outlook": "sunny"})
+	assert.NoError(t, err)
+	assert.NotNil(t, vote)
+}
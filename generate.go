@@ -0,0 +1,328 @@
+package dtree
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// randomAttempts is the number of times a request is resolved when its path goes through random nodes
+var randomAttempts = 200
+
+// LeafRequest is a request that Resolve routes to Leaf
+type LeafRequest struct {
+	Leaf    *Tree
+	Request map[string]interface{}
+	// Random is true when the path goes through percent (or ab without value) nodes,
+	// the request reaches the leaf only for some of the resolutions
+	Random bool
+}
+
+// Reachability lists a request for each reachable leaf, and the leaves that cannot be reached
+type Reachability struct {
+	Requests    []LeafRequest
+	Unreachable []*Tree
+}
+
+// constraint is the expected result of the evaluation of a node on the path to a leaf
+type constraint struct {
+	node *Tree
+	// target is the child that must be selected under the parent of node
+	target *Tree
+}
+
+// GenerateRequests derives, for every leaf of the tree, the conditions along its path
+// and builds a request that Resolve routes to this leaf. Leaves for which no request
+// was found are returned as Unreachable.
+// The options are the ones given to Resolve (custom operators, StopIfConvertingError ...)
+func GenerateRequests(t *Tree, options ...func(t *TreeOptions)) *Reachability {
+	result := &Reachability{}
+	if t == nil {
+		return result
+	}
+
+	config := newTreeOptions(&TreeOptions{}, options...)
+
+	var visit func(n *Tree, constraints []constraint, random bool)
+	visit = func(n *Tree, constraints []constraint, random bool) {
+		if len(n.GetChild()) == 0 {
+			request, ok := requestFor(t, n, constraints, random, config, options)
+			if !ok {
+				result.Unreachable = append(result.Unreachable, n)
+				return
+			}
+			result.Requests = append(result.Requests, LeafRequest{Leaf: n, Request: request, Random: random})
+			return
+		}
+
+		for i, child := range n.GetChild() {
+			c := make([]constraint, len(constraints), len(constraints)+i+1)
+			copy(c, constraints)
+			r := random
+			for _, sibling := range n.GetChild()[:i+1] {
+				if isRandomNode(sibling) {
+					r = true
+					continue
+				}
+				c = append(c, constraint{node: sibling, target: child})
+			}
+			visit(child, c, r)
+		}
+	}
+	visit(t, nil, false)
+
+	return result
+}
+
+// requestFor builds a request matching the constraints, and checks that it is resolved to leaf
+func requestFor(t *Tree, leaf *Tree, constraints []constraint, random bool, config *TreeOptions, options []func(t *TreeOptions)) (map[string]interface{}, bool) {
+	var keys []string
+	byKey := make(map[string][]constraint)
+	for _, c := range constraints {
+		if _, ok := byKey[c.node.Key]; !ok {
+			keys = append(keys, c.node.Key)
+		}
+		byKey[c.node.Key] = append(byKey[c.node.Key], c)
+	}
+
+	request := make(map[string]interface{})
+	for _, key := range keys {
+		if !dependsOnRequest(byKey[key]) {
+			continue
+		}
+
+		found := false
+		for _, candidate := range candidates(byKey[key]) {
+			if candidate == nil {
+				delete(request, key)
+			} else {
+				request[key] = candidate
+			}
+
+			if satisfies(request, candidate, byKey[key], config) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	attempts := 1
+	if random {
+		attempts = randomAttempts
+	}
+
+	for i := 0; i < attempts; i++ {
+		if r, _ := t.Resolve(request, options...); r == leaf {
+			return request, true
+		}
+	}
+
+	return nil, false
+}
+
+// satisfies evaluates the nodes as Next does, with value as request value
+func satisfies(request map[string]interface{}, value interface{}, constraints []constraint, config *TreeOptions) bool {
+	for _, c := range constraints {
+		selected, err := compare(request, value, c.node, config)
+		if err != nil && config.StopIfConvertingError {
+			return false
+		}
+
+		if c.node == c.target {
+			if selected != c.target {
+				return false
+			}
+			continue
+		}
+
+		// ab nodes select one of their siblings, the first one evaluated decides
+		if selected != nil && !(c.node.Operator == "ab" && selected == c.target) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dependsOnRequest returns false if the constraints are on nodes without condition (fallback or no operator),
+// they are always selected whatever the request
+func dependsOnRequest(constraints []constraint) bool {
+	for _, c := range constraints {
+		if !isFallback(c.node) && c.node.Operator != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRandomNode returns true if the node selection does not depend on the request
+func isRandomNode(n *Tree) bool {
+	switch n.Operator {
+	case "percent", "%":
+		return true
+	case "ab":
+		return n.Key == ""
+	}
+	return false
+}
+
+// candidates returns values that may satisfy the constraints of a key, nil meaning the key is absent
+func candidates(constraints []constraint) []interface{} {
+	var values []interface{}
+	var counts []int
+	positive := false
+	ab := false
+
+	add := func(v interface{}) {
+		for _, existing := range values {
+			if existing == v {
+				return
+			}
+		}
+		values = append(values, v)
+	}
+
+	for _, c := range constraints {
+		if c.node == c.target {
+			positive = true
+		}
+
+		switch canonicalOperator(c.node.Operator) {
+		case "count":
+			if v, ok := c.node.Value.(float64); ok && v >= 0 {
+				counts = append(counts, int(v), int(v)+1)
+			}
+			continue
+		case "ab":
+			ab = true
+			continue
+		case "regexp":
+			if s, ok := c.node.Value.(string); ok {
+				if m, ok := regexpSample(s); ok {
+					add(m)
+				}
+			}
+		case "contains":
+			if s, ok := c.node.Value.(string); ok {
+				add(s)
+				add("_" + s + "_")
+			}
+			continue
+		}
+
+		for _, v := range valuesOf(c.node.Value) {
+			switch tv := v.(type) {
+			case float64:
+				add(tv)
+				add(tv + 1)
+				add(tv - 1)
+				add(tv + 0.5)
+				add(tv - 0.5)
+			case string:
+				add(tv)
+				add(tv + "_")
+				if len(tv) > 0 {
+					add(tv[:len(tv)-1])
+				}
+			case bool:
+				add(true)
+				add(false)
+			}
+		}
+	}
+
+	// ab nodes are random for the values that are not strings
+	if ab {
+		values = nil
+		for i := 0; i < 1000; i++ {
+			values = append(values, fmt.Sprintf("user-%d", i))
+		}
+		return append(values, nil)
+	}
+
+	add("")
+	add(0.0)
+
+	for _, n := range counts {
+		item := interface{}("item")
+		for _, v := range values {
+			if s, ok := v.(string); ok && s != "" {
+				item = v
+				break
+			}
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = item
+		}
+		values = append([]interface{}{items}, values...)
+	}
+
+	// without condition to match, an absent key is the simplest request
+	if !positive {
+		return append([]interface{}{nil}, values...)
+	}
+	return append(values, nil)
+}
+
+// regexpSample builds a string matched by the pattern
+func regexpSample(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if !writeRegexpSample(&b, re.Simplify()) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func writeRegexpSample(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		b.WriteRune(re.Rune[0])
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune('a')
+	case syntax.OpCapture:
+		return writeRegexpSample(b, re.Sub[0])
+	case syntax.OpPlus:
+		return writeRegexpSample(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writeRegexpSample(b, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeRegexpSample(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			var alt strings.Builder
+			if writeRegexpSample(&alt, sub) {
+				b.WriteString(alt.String())
+				return true
+			}
+		}
+		return false
+	}
+
+	// empty matches, anchors, star and quest add nothing
+	return true
+}
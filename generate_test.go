@@ -0,0 +1,106 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRequests(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+
+	// Act
+	result := GenerateRequests(tr)
+
+	// Assert
+	assert.Len(t, result.Requests, 4)
+	assert.Empty(t, result.Unreachable)
+	for _, r := range result.Requests {
+		node, err := tr.Resolve(r.Request)
+		assert.NoError(t, err)
+		assert.Equal(t, r.Leaf, node, "the generated request should be resolved to its leaf")
+		assert.False(t, r.Random)
+	}
+}
+
+func TestGenerateRequests_Operators(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root"},
+		{"id": 2, "parent_id": 1, "key": "email", "operator": "regexp", "value": "^[a-z]+@example\\.(com|org)$", "order": 1},
+		{"id": 3, "parent_id": 2, "name": "example"},
+		{"id": 4, "parent_id": 1, "key": "tags", "operator": "count", "value": 2, "order": 2},
+		{"id": 5, "parent_id": 4, "name": "two tags"},
+		{"id": 6, "parent_id": 1, "key": "name", "operator": "contains", "value": "bob", "order": 3},
+		{"id": 7, "parent_id": 6, "name": "bob"},
+		{"id": 8, "parent_id": 1, "key": "user", "operator": "ab", "value": 30, "order": 4},
+		{"id": 9, "parent_id": 8, "name": "A"},
+		{"id": 10, "parent_id": 1, "key": "user", "operator": "ab", "value": 70, "order": 5},
+		{"id": 11, "parent_id": 10, "name": "B"}
+	]`))
+	assert.NoError(t, err)
+
+	// Act
+	result := GenerateRequests(tr)
+
+	// Assert
+	assert.Empty(t, result.Unreachable)
+	assert.Len(t, result.Requests, 5)
+	for _, r := range result.Requests {
+		node, _ := tr.Resolve(r.Request)
+		assert.Equal(t, r.Leaf.Name, node.Name, "the generated request should be resolved to its leaf")
+		assert.NotContains(t, r.Request, "", "nodes without condition should not add keys to the request")
+	}
+}
+
+func TestGenerateRequests_Unreachable(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root"},
+		{"id": 2, "parent_id": 1, "key": "age", "operator": "gt", "value": 10, "order": 1},
+		{"id": 3, "parent_id": 2, "name": "adult"},
+		{"id": 4, "parent_id": 1, "key": "age", "operator": "gt", "value": 20, "order": 2},
+		{"id": 5, "parent_id": 4, "name": "never"}
+	]`))
+	assert.NoError(t, err)
+
+	// Act
+	result := GenerateRequests(tr)
+
+	// Assert
+	assert.Len(t, result.Requests, 1)
+	if assert.Len(t, result.Unreachable, 1) {
+		assert.Equal(t, 5, result.Unreachable[0].ID)
+	}
+}
+
+func TestGenerateRequests_Percent(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root"},
+		{"id": 2, "parent_id": 1, "operator": "percent", "value": 50},
+		{"id": 3, "parent_id": 1, "operator": "percent", "value": 50}
+	]`))
+	assert.NoError(t, err)
+
+	// Act
+	result := GenerateRequests(tr)
+
+	// Assert
+	assert.Empty(t, result.Unreachable)
+	if assert.Len(t, result.Requests, 2) {
+		assert.True(t, result.Requests[0].Random)
+	}
+}
+
+func TestRegexpSample(t *testing.T) {
+	for _, pattern := range []string{"^abc$", "a+b*c?", "[0-9]{3}-x", "(foo|bar)baz", `\d+\.\w`} {
+		s, ok := regexpSample(pattern)
+		assert.True(t, ok, pattern)
+
+		n, _ := regex(s, &Tree{Value: pattern})
+		assert.NotNil(t, n, "%s should match %q", s, pattern)
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tkanos/go-dtree"
+)
+
+// TreeVersion is the response describing a version of a tree
+type TreeVersion struct {
+	Name    string       `json:"name"`
+	Version int          `json:"version"`
+	Nodes   []dtree.Tree `json:"nodes"`
+}
+
+// Change is the response of a change of a tree
+type Change struct {
+	Version int `json:"version"`
+	// ID is the id of the added node
+	ID int `json:"id,omitempty"`
+}
+
+// move is the body of a move
+type move struct {
+	ParentID int `json:"parent_id"`
+	Order    int `json:"order"`
+}
+
+// admin routes the edition endpoints of a tree, rest being the parts of the path after its name
+func (s *Server) admin(w http.ResponseWriter, r *http.Request, name string, rest []string) {
+	expected, err := ifMatch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(rest) == 0 && r.Method == http.MethodPut {
+		s.replace(w, r, name, expected)
+		return
+	}
+
+	store, ok := s.Store(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, errUnknownTree(name))
+		return
+	}
+
+	var id int
+	if len(rest) >= 2 && rest[0] == "nodes" {
+		if id, err = strconv.Atoi(rest[1]); err != nil {
+			writeError(w, http.StatusNotFound, errors.New("invalid node id "+strconv.Quote(rest[1])))
+			return
+		}
+	}
+
+	switch {
+	case len(rest) == 0:
+		if allow(w, r, http.MethodGet) {
+			v := store.Current()
+			w.Header().Set("ETag", etag(v.Number))
+			writeJSON(w, http.StatusOK, TreeVersion{Name: name, Version: v.Number, Nodes: v.Tree.Flatten()})
+		}
+	case len(rest) == 1 && rest[0] == "nodes":
+		var node dtree.Tree
+		if allow(w, r, http.MethodPost) && s.decode(w, r, &node) {
+			s.edit(w, store, expected, http.StatusCreated, func(e *dtree.Editor) (int, error) {
+				return e.Add(node)
+			})
+		}
+	case len(rest) == 2 && rest[0] == "nodes" && r.Method == http.MethodDelete:
+		s.edit(w, store, expected, http.StatusOK, func(e *dtree.Editor) (int, error) {
+			return 0, e.Delete(id)
+		})
+	case len(rest) == 2 && rest[0] == "nodes":
+		var node dtree.Tree
+		if allow(w, r, http.MethodPut) && s.decode(w, r, &node) {
+			node.ID = id
+			s.edit(w, store, expected, http.StatusOK, func(e *dtree.Editor) (int, error) {
+				return 0, e.Update(node)
+			})
+		}
+	case len(rest) == 3 && rest[0] == "nodes" && rest[2] == "move":
+		var m move
+		if allow(w, r, http.MethodPost) && s.decode(w, r, &m) {
+			s.edit(w, store, expected, http.StatusOK, func(e *dtree.Editor) (int, error) {
+				return 0, e.Move(id, m.ParentID, m.Order)
+			})
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// replace publishes the nodes of the body as a new version of the tree, or creates it
+func (s *Server) replace(w http.ResponseWriter, r *http.Request, name string, expected int) {
+	var nodes []dtree.Tree
+	if !s.decode(w, r, &nodes) {
+		return
+	}
+
+	s.mu.Lock()
+	store, ok := s.trees[name]
+	if !ok {
+		if err := dtree.Validate(nodes, s.options.TreeOptions...); err != nil {
+			s.mu.Unlock()
+			writeChangeError(w, err)
+			return
+		}
+		store = dtree.NewStore(dtree.CreateTree(nodes), s.options.TreeOptions...)
+		s.trees[name] = store
+		s.mu.Unlock()
+
+		w.Header().Set("ETag", etag(1))
+		writeJSON(w, http.StatusCreated, Change{Version: 1})
+		return
+	}
+	s.mu.Unlock()
+
+	v, err := store.Publish(expected, nodes)
+	if err != nil {
+		writeChangeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(v.Number))
+	writeJSON(w, http.StatusOK, Change{Version: v.Number})
+}
+
+// edit publishes the change of the tree
+func (s *Server) edit(w http.ResponseWriter, store *dtree.Store, expected int, status int, change func(e *dtree.Editor) (int, error)) {
+	var id int
+	v, err := store.Edit(expected, func(e *dtree.Editor) error {
+		var err error
+		id, err = change(e)
+		return err
+	})
+	if err != nil {
+		writeChangeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(v.Number))
+	writeJSON(w, status, Change{Version: v.Number, ID: id})
+}
+
+// writeChangeError writes the error of a change with its status
+func writeChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, dtree.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, dtree.ErrNodeNotFound):
+		writeError(w, http.StatusNotFound, err)
+	default:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	}
+}
+
+// ifMatch returns the version expected by the If-Match header, 0 without header
+func ifMatch(r *http.Request) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		return 0, errors.New("invalid If-Match " + strconv.Quote(header))
+	}
+	return version, nil
+}
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
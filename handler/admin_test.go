@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkanos/go-dtree"
+)
+
+func serve(s *Server, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Admin(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	s.options.Admin = true
+	s.options.TreeOptions = nil
+	before, _ := s.Store("hello")
+	old := before.Current().Tree
+
+	// Act
+	added := serve(s, http.MethodPost, "/trees/hello/nodes", `{"parent_id": 1, "key": "sayHello", "operator": "eq", "value": "maybe"}`, "If-Match", `"1"`)
+	leaf := serve(s, http.MethodPost, "/trees/hello/nodes", `{"parent_id": 14, "name": "Perhaps", "order": 1}`)
+	updated := serve(s, http.MethodPut, "/trees/hello/nodes/13", `{"name": "Hey dude"}`)
+	moved := serve(s, http.MethodPost, "/trees/hello/nodes/4/move", `{"parent_id": 14, "order": 2}`)
+	deleted := serve(s, http.MethodDelete, "/trees/hello/nodes/3", ``)
+	got := serve(s, http.MethodGet, "/trees/hello", ``)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, added.Code, added.Body.String())
+	assert.JSONEq(t, `{"version": 2, "id": 14}`, added.Body.String())
+	assert.Equal(t, `"2"`, added.Header().Get("ETag"))
+	assert.Equal(t, http.StatusCreated, leaf.Code)
+	assert.Equal(t, http.StatusOK, updated.Code)
+	assert.Equal(t, http.StatusOK, moved.Code)
+	assert.Equal(t, http.StatusOK, deleted.Code)
+	assert.JSONEq(t, `{"version": 6}`, deleted.Body.String())
+
+	var v TreeVersion
+	assert.NoError(t, json.Unmarshal(got.Body.Bytes(), &v))
+	assert.Equal(t, 6, v.Version)
+	assert.Len(t, v.Nodes, 14)
+
+	resolved := serve(s, http.MethodPost, "/trees/hello/resolve", `{"sayHello": "maybe"}`)
+	assert.Contains(t, resolved.Body.String(), `"name":"Perhaps"`)
+	resolved = serve(s, http.MethodPost, "/trees/hello/resolve", `{"sayHello": true, "gender": "M", "age": 30}`)
+	assert.Contains(t, resolved.Body.String(), `"name":"Hey dude"`)
+
+	// the previous version is untouched
+	node, err := old.Resolve(map[string]interface{}{"sayHello": false})
+	assert.NoError(t, err)
+	assert.Equal(t, "Goodbye", node.Name)
+}
+
+func TestServer_Admin_Errors(t *testing.T) {
+	s := newTestServer(t)
+	s.options.Admin = true
+
+	tests := []struct {
+		method  string
+		path    string
+		body    string
+		ifMatch string
+		status  int
+	}{
+		{http.MethodPost, "/trees/hello/nodes", `{"parent_id": 99}`, "", http.StatusNotFound},
+		{http.MethodPost, "/trees/hello/nodes", `{"parent_id": 1, "operator": "unknown"}`, "", http.StatusUnprocessableEntity},
+		{http.MethodPost, "/trees/hello/nodes/2/move", `{"parent_id": 5}`, "", http.StatusUnprocessableEntity},
+		{http.MethodDelete, "/trees/hello/nodes/1", ``, "", http.StatusUnprocessableEntity},
+		{http.MethodDelete, "/trees/hello/nodes/4", ``, `"7"`, http.StatusPreconditionFailed},
+		{http.MethodDelete, "/trees/hello/nodes/4", ``, `seven`, http.StatusBadRequest},
+		{http.MethodPut, "/trees/hello", `[{"id": 1}, {"id": 2}]`, "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/trees/unknown", ``, "", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		w := serve(s, test.method, test.path, test.body, "If-Match", test.ifMatch)
+
+		assert.Equal(t, test.status, w.Code, "%s %s %s", test.method, test.path, w.Body.String())
+	}
+
+	store, _ := s.Store("hello")
+	assert.Equal(t, 1, store.Current().Number, "no invalid change should be published")
+}
+
+func TestServer_Admin_Replace(t *testing.T) {
+	// Arrange
+	s := New(map[string]*dtree.Tree{}, func(o *Options) {
+		o.Admin = true
+	})
+
+	// Act
+	created := serve(s, http.MethodPut, "/trees/bye", `[{"id": 1, "name": "Goodbye"}]`)
+	replaced := serve(s, http.MethodPut, "/trees/bye", `[{"id": 1, "name": "Bye"}]`, "If-Match", `"1"`)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.Equal(t, http.StatusOK, replaced.Code, replaced.Body.String())
+	assert.JSONEq(t, `{"version": 2}`, replaced.Body.String())
+	assert.Contains(t, serve(s, http.MethodPost, "/trees/bye/resolve", `{}`).Body.String(), `"name":"Bye"`)
+}
+
+func TestServer_Without_Admin(t *testing.T) {
+	s := newTestServer(t)
+
+	w := serve(s, http.MethodDelete, "/trees/hello/nodes/4", ``)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,221 @@
+// Package handler exposes dtree decision trees over http.
+//
+// A Server hosts named trees and offers:
+//
+//	GET  /health                 the status of the service
+//	GET  /trees                  the names and sizes of the hosted trees
+//	POST /trees/{name}/resolve   resolve the json request of the body (?trace=true to get the decision path)
+//
+// With Options.Admin, it also offers the edition of the trees, every change being validated then published
+// as a new version (see dtree.Store). The version is returned on the ETag header and can be expected with
+// the If-Match header:
+//
+//	GET    /trees/{name}                   the version and the nodes of the tree
+//	PUT    /trees/{name}                   replace all the nodes of the tree (or create it)
+//	POST   /trees/{name}/nodes             add the node of the body (its id is given if it has none)
+//	PUT    /trees/{name}/nodes/{id}        update the node (its parent is kept)
+//	POST   /trees/{name}/nodes/{id}/move   move the node under {"parent_id": 1, "order": 2}
+//	DELETE /trees/{name}/nodes/{id}        delete the node and its descendants
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tkanos/go-dtree"
+)
+
+// Options allow to configure a Server
+type Options struct {
+	// TreeOptions are given to every resolution
+	TreeOptions []func(t *dtree.TreeOptions)
+	// MaxBodySize is the maximum size of a request body, in bytes (1MB by default)
+	MaxBodySize int64
+	// Admin enables the endpoints editing the trees
+	Admin bool
+}
+
+// Result is the response of a resolution
+type Result struct {
+	Version int         `json:"version"`
+	ID      int         `json:"id"`
+	Name    string      `json:"name"`
+	Content interface{} `json:"content"`
+	Trace   []string    `json:"trace,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// TreeInfo describes a hosted tree
+type TreeInfo struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Nodes   int    `json:"nodes"`
+}
+
+// Server is an http.Handler hosting named trees, safe for concurrent use.
+// Replacing or editing a tree does not affect the resolutions already running on the previous version.
+type Server struct {
+	mu      sync.RWMutex
+	trees   map[string]*dtree.Store
+	options Options
+}
+
+// New creates a Server hosting the trees by name
+func New(trees map[string]*dtree.Tree, options ...func(o *Options)) *Server {
+	config := Options{MaxBodySize: 1 << 20}
+	for _, option := range options {
+		option(&config)
+	}
+
+	s := &Server{trees: make(map[string]*dtree.Store, len(trees)), options: config}
+	for name, t := range trees {
+		s.trees[name] = dtree.NewStore(t, config.TreeOptions...)
+	}
+
+	return s
+}
+
+// Set hosts (or replaces) a tree under a name, as its version 1
+func (s *Server) Set(name string, t *dtree.Tree) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trees[name] = dtree.NewStore(t, s.options.TreeOptions...)
+}
+
+// Remove stops hosting a tree
+func (s *Server) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.trees, name)
+}
+
+// Store returns the store of the tree hosted under a name
+func (s *Server) Store(name string) (*dtree.Store, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	store, ok := s.trees[name]
+	return store, ok
+}
+
+// Trees returns the hosted trees, sorted by name
+func (s *Server) Trees() []TreeInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]TreeInfo, 0, len(s.trees))
+	for name, store := range s.trees {
+		v := store.Current()
+		infos = append(infos, TreeInfo{Name: name, Version: v.Number, Nodes: len(v.Tree.Flatten())})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// ServeHTTP routes the request to the endpoints of the Server
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "health":
+		if allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		}
+	case path == "trees":
+		if allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, s.Trees())
+		}
+	case len(parts) == 3 && parts[0] == "trees" && parts[2] == "resolve":
+		if allow(w, r, http.MethodPost) {
+			s.resolve(w, r, parts[1])
+		}
+	case s.options.Admin && len(parts) >= 2 && parts[0] == "trees":
+		s.admin(w, r, parts[1], parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// resolve resolves the json request of the body against the tree
+func (s *Server) resolve(w http.ResponseWriter, r *http.Request, name string) {
+	store, ok := s.Store(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, errUnknownTree(name))
+		return
+	}
+
+	var request map[string]interface{}
+	if !s.decode(w, r, &request) {
+		return
+	}
+
+	trace, _ := strconv.ParseBool(r.URL.Query().Get("trace"))
+
+	v := store.Current()
+	node, ctx, err := v.Tree.ResolveWithContext(context.Background(), request, s.options.TreeOptions...)
+	result := Result{Version: v.Number}
+	if node != nil {
+		result.ID = node.ID
+		result.Name = node.Name
+		result.Content = node.Content
+	}
+	if trace {
+		result.Trace = dtree.GetNodePathFromContext(ctx)
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		result.Error = err.Error()
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, result)
+}
+
+// decode reads the json body of the request on v, or writes a 400
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.options.MaxBodySize))
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	return true
+}
+
+func errUnknownTree(name string) error {
+	return errors.New("unknown tree " + strconv.Quote(name))
+}
+
+// allow writes a 405 if the method of the request is not the expected one
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkanos/go-dtree"
+)
+
+func newTestServer(t *testing.T) *Server {
+	b, err := ioutil.ReadFile("../testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := dtree.LoadTree(b)
+	assert.NoError(t, err)
+
+	return New(map[string]*dtree.Tree{"hello": tr}, func(o *Options) {
+		o.TreeOptions = []func(t *dtree.TreeOptions){func(t *dtree.TreeOptions) {
+			t.StopIfConvertingError = true
+		}}
+	})
+}
+
+func TestServer_Resolve(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	r := httptest.NewRequest(http.MethodPost, "/trees/hello/resolve?trace=true", strings.NewReader(`{"sayHello": true, "gender": "M", "age": 70}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	s.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var result Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 11, result.ID)
+	assert.Equal(t, "Hello Sir", result.Name)
+	assert.Len(t, result.Trace, 4)
+	assert.Empty(t, result.Error)
+}
+
+func TestServer_Resolve_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/trees/unknown/resolve", `{}`, http.StatusNotFound},
+		{http.MethodPost, "/trees/hello/resolve", `not a json`, http.StatusBadRequest},
+		{http.MethodPost, "/trees/hello/resolve", `{"sayHello": "yes"}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/trees/hello/resolve", ``, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/unknown", ``, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+
+		assert.Equal(t, test.status, w.Code, "%s %s %s", test.method, test.path, test.body)
+		assert.Contains(t, w.Body.String(), `"error"`)
+	}
+}
+
+func TestServer_Health_And_Trees(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	s.Set("goodbye", dtree.CreateTree([]dtree.Tree{{ID: 1, Name: "Goodbye"}}))
+
+	// Act
+	health := httptest.NewRecorder()
+	s.ServeHTTP(health, httptest.NewRequest(http.MethodGet, "/health", nil))
+	trees := httptest.NewRecorder()
+	s.ServeHTTP(trees, httptest.NewRequest(http.MethodGet, "/trees", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, health.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, health.Body.String())
+	assert.Equal(t, http.StatusOK, trees.Code)
+	assert.JSONEq(t, `[{"name": "goodbye", "version": 1, "nodes": 1}, {"name": "hello", "version": 1, "nodes": 13}]`, trees.Body.String())
+
+	s.Remove("goodbye")
+	assert.Len(t, s.Trees(), 1)
+}
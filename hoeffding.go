@@ -0,0 +1,443 @@
+package dtree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// HoeffdingOptions allow to configure a Hoeffding tree
+type HoeffdingOptions struct {
+	// GracePeriod is the number of samples a leaf learns between two split attempts (200 by default)
+	GracePeriod int
+	// Delta is the probability that a split is not the one which would be chosen with all the samples (1e-7 by default)
+	Delta float64
+	// TieThreshold splits on the best key when the Hoeffding bound becomes lower, even if
+	// a second key is as good (0.05 by default)
+	TieThreshold float64
+	// MaxDepth is the maximum number of conditions from the root to a leaf (0 means no limit)
+	MaxDepth int
+	// NumericSplits is the number of thresholds tried on a numeric key (10 by default)
+	NumericSplits int
+	// Features are the keys of the requests that can be used, all of them by default
+	Features []string
+}
+
+// HoeffdingTree is a classification tree learned from a stream of samples (VFDT): a leaf
+// is split once the Hoeffding bound shows, with a probability 1 - Delta, that the best key
+// is better than the others. Learn and Snapshot can be called concurrently.
+//
+//	h := dtree.NewHoeffdingTree()
+//	go func() {
+//		for s := range stream {
+//			h.Learn(s)
+//		}
+//	}()
+//	tree := h.Snapshot() // served with Resolve while the learning continues
+type HoeffdingTree struct {
+	mu      sync.RWMutex
+	config  *HoeffdingOptions
+	allowed map[string]bool
+	root    *hoeffdingNode
+	samples int
+}
+
+// hoeffdingNode is a node of a Hoeffding tree, its leaves observe the samples to choose their split
+type hoeffdingNode struct {
+	key      string
+	operator string
+	value    interface{}
+	children []*hoeffdingNode
+	depth    int
+
+	// stats is the distribution of the labels of the node
+	stats *classStats
+	// observed is the weight of the samples learned by the leaf since its creation
+	observed float64
+	// lastAttempt is the value of observed at the last split attempt
+	lastAttempt float64
+	// categorical counts the labels by value, numeric estimates their distribution, for each key
+	categorical     map[string]map[interface{}]*classStats
+	numeric         map[string]*numericObserver
+	usedCategorical map[string]bool
+}
+
+// numericObserver estimates the distribution of a numeric key with a normal distribution by label
+type numericObserver struct {
+	min, max float64
+	byLabel  map[interface{}]*gaussian
+}
+
+// gaussian computes the mean and the variance of a stream of numbers (Welford)
+type gaussian struct {
+	weight, mean, m2 float64
+}
+
+func (g *gaussian) add(v float64, weight float64) {
+	g.weight += weight
+	delta := v - g.mean
+	g.mean += weight * delta / g.weight
+	g.m2 += weight * delta * (v - g.mean)
+}
+
+// weightLTE estimates the weight of the values lower or equal to the threshold
+func (g *gaussian) weightLTE(threshold float64) float64 {
+	if g.weight <= 1 || g.m2 <= 0 {
+		if threshold >= g.mean {
+			return g.weight
+		}
+		return 0
+	}
+
+	stdDev := math.Sqrt(g.m2 / (g.weight - 1))
+	return g.weight * 0.5 * (1 + math.Erf((threshold-g.mean)/(stdDev*math.Sqrt2)))
+}
+
+// NewHoeffdingTree creates an empty Hoeffding tree
+func NewHoeffdingTree(options ...func(o *HoeffdingOptions)) *HoeffdingTree {
+	config := &HoeffdingOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.GracePeriod <= 0 {
+		config.GracePeriod = 200
+	}
+	if config.Delta <= 0 {
+		config.Delta = 1e-7
+	}
+	if config.TieThreshold <= 0 {
+		config.TieThreshold = 0.05
+	}
+	if config.NumericSplits <= 0 {
+		config.NumericSplits = 10
+	}
+
+	h := &HoeffdingTree{config: config, allowed: make(map[string]bool)}
+	for _, f := range config.Features {
+		h.allowed[f] = true
+	}
+	h.root = h.newLeaf(Tree{}, 0, nil, nil)
+
+	return h
+}
+
+func (h *HoeffdingTree) newLeaf(condition Tree, depth int, stats *classStats, usedCategorical map[string]bool) *hoeffdingNode {
+	if stats == nil {
+		stats = &classStats{counts: make(map[interface{}]float64)}
+	}
+	return &hoeffdingNode{
+		key:             condition.Key,
+		operator:        condition.Operator,
+		value:           condition.Value,
+		depth:           depth,
+		stats:           stats,
+		categorical:     make(map[string]map[interface{}]*classStats),
+		numeric:         make(map[string]*numericObserver),
+		usedCategorical: usedCategorical,
+	}
+}
+
+// Samples returns the number of learned samples
+func (h *HoeffdingTree) Samples() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.samples
+}
+
+// Learn updates the tree with a sample, whose label must be a string, a number or a bool.
+// The sample goes down the tree like a resolution, and is learned by the leaf it reaches
+// (or by the split node if it has no value for the key of the split).
+func (h *HoeffdingTree) Learn(s Sample) error {
+	switch s.Label.(type) {
+	case string, float64, bool:
+	default:
+		return ErrBadLabel
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples++
+	row := trainRow{request: s.Request, label: s.Label, weight: 1}
+	n := h.root
+	for {
+		n.stats.add(row, 1)
+		if len(n.children) == 0 {
+			break
+		}
+
+		next := n.route(s.Request)
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+
+	h.observe(n, row)
+	if n.observed-n.lastAttempt >= float64(h.config.GracePeriod) && (h.config.MaxDepth == 0 || n.depth < h.config.MaxDepth) {
+		n.lastAttempt = n.observed
+		h.attemptSplit(n)
+	}
+
+	return nil
+}
+
+// route returns the child selected by the request, nil if none
+func (n *hoeffdingNode) route(request map[string]interface{}) *hoeffdingNode {
+	for _, child := range n.children {
+		v, ok := request[child.key]
+		if !ok {
+			return nil
+		}
+
+		switch child.operator {
+		case "eq":
+			if v == child.value {
+				return child
+			}
+		case "lte":
+			if f, ok := v.(float64); ok && f <= child.value.(float64) {
+				return child
+			}
+		case "gt":
+			if f, ok := v.(float64); ok && f > child.value.(float64) {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// observe updates the observers of the leaf with the values of the sample
+func (h *HoeffdingTree) observe(n *hoeffdingNode, row trainRow) {
+	n.observed += row.weight
+	for k, v := range row.request {
+		if len(h.allowed) > 0 && !h.allowed[k] {
+			continue
+		}
+
+		switch value := v.(type) {
+		case float64:
+			if _, ok := n.categorical[k]; ok {
+				continue
+			}
+			o, ok := n.numeric[k]
+			if !ok {
+				o = &numericObserver{min: value, max: value, byLabel: make(map[interface{}]*gaussian)}
+				n.numeric[k] = o
+			}
+			o.min, o.max = math.Min(o.min, value), math.Max(o.max, value)
+			if _, ok := o.byLabel[row.label]; !ok {
+				o.byLabel[row.label] = &gaussian{}
+			}
+			o.byLabel[row.label].add(value, row.weight)
+		case string, bool:
+			if _, ok := n.numeric[k]; ok || n.usedCategorical[k] {
+				continue
+			}
+			if _, ok := n.categorical[k]; !ok {
+				n.categorical[k] = make(map[interface{}]*classStats)
+			}
+			if _, ok := n.categorical[k][value]; !ok {
+				n.categorical[k][value] = &classStats{counts: make(map[interface{}]float64)}
+			}
+			n.categorical[k][value].add(row, 1)
+		}
+	}
+}
+
+// hoeffdingSplit is a candidate split of a leaf
+type hoeffdingSplit struct {
+	key       string
+	threshold float64
+	values    []interface{}
+	parts     []*classStats
+	gain      float64
+}
+
+// attemptSplit splits the leaf if the best split is better than the second one (or than no split)
+// by more than the Hoeffding bound
+func (h *HoeffdingTree) attemptSplit(n *hoeffdingNode) {
+	if len(n.stats.counts) < 2 {
+		return
+	}
+
+	var candidates []*hoeffdingSplit
+	for k := range n.categorical {
+		if s := n.categoricalSplit(k); s != nil {
+			candidates = append(candidates, s)
+		}
+	}
+	for k := range n.numeric {
+		if s := n.numericSplit(k, h.config.NumericSplits); s != nil {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].gain != candidates[j].gain {
+			return candidates[i].gain > candidates[j].gain
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	best := candidates[0]
+	second := 0.0
+	if len(candidates) > 1 {
+		second = math.Max(0, candidates[1].gain)
+	}
+
+	valueRange := math.Log2(float64(len(n.stats.counts)))
+	bound := math.Sqrt(valueRange * valueRange * math.Log(1/h.config.Delta) / (2 * n.observed))
+	if best.gain <= 0 || (best.gain-second <= bound && bound >= h.config.TieThreshold) {
+		return
+	}
+
+	if best.values == nil {
+		n.children = []*hoeffdingNode{
+			h.newLeaf(Tree{Key: best.key, Operator: "lte", Value: best.threshold}, n.depth+1, best.parts[0], n.usedCategorical),
+			h.newLeaf(Tree{Key: best.key, Operator: "gt", Value: best.threshold}, n.depth+1, best.parts[1], n.usedCategorical),
+		}
+	} else {
+		used := make(map[string]bool, len(n.usedCategorical)+1)
+		for k := range n.usedCategorical {
+			used[k] = true
+		}
+		used[best.key] = true
+
+		for i, v := range best.values {
+			n.children = append(n.children, h.newLeaf(Tree{Key: best.key, Operator: "eq", Value: v}, n.depth+1, best.parts[i], used))
+		}
+	}
+
+	n.categorical, n.numeric = nil, nil
+}
+
+// gain returns the information gain of a split: the decrease of entropy of the samples having
+// the key, weighted by their part of the leaf
+func (n *hoeffdingNode) gain(known *classStats, parts []*classStats) float64 {
+	if known.weight() <= 0 {
+		return 0
+	}
+
+	var children float64
+	for _, p := range parts {
+		children += p.weight() / known.weight() * p.impurity()
+	}
+	return known.weight() / n.stats.weight() * (known.impurity() - children)
+}
+
+func (n *hoeffdingNode) categoricalSplit(key string) *hoeffdingSplit {
+	byValue := n.categorical[key]
+	if len(byValue) < 2 {
+		return nil
+	}
+
+	s := &hoeffdingSplit{key: key}
+	for v := range byValue {
+		s.values = append(s.values, v)
+	}
+	sort.Slice(s.values, func(i, j int) bool {
+		return fmt.Sprint(s.values[i]) < fmt.Sprint(s.values[j])
+	})
+
+	known := &classStats{counts: make(map[interface{}]float64)}
+	for _, v := range s.values {
+		part := byValue[v]
+		s.parts = append(s.parts, &classStats{counts: copyCounts(part.counts), total: part.total})
+		for label, c := range part.counts {
+			known.counts[label] += c
+			known.total += c
+		}
+	}
+
+	s.gain = n.gain(known, s.parts)
+	return s
+}
+
+func (n *hoeffdingNode) numericSplit(key string, splits int) *hoeffdingSplit {
+	o := n.numeric[key]
+	if o.max <= o.min {
+		return nil
+	}
+
+	known := &classStats{counts: make(map[interface{}]float64)}
+	for label, g := range o.byLabel {
+		known.counts[label] += g.weight
+		known.total += g.weight
+	}
+
+	var best *hoeffdingSplit
+	for i := 1; i <= splits; i++ {
+		threshold := o.min + (o.max-o.min)*float64(i)/float64(splits+1)
+		left := &classStats{counts: make(map[interface{}]float64)}
+		right := &classStats{counts: make(map[interface{}]float64)}
+		for label, g := range o.byLabel {
+			w := g.weightLTE(threshold)
+			left.counts[label], left.total = w, left.total+w
+			right.counts[label], right.total = g.weight-w, right.total+g.weight-w
+		}
+
+		gain := n.gain(known, []*classStats{left, right})
+		if best == nil || gain > best.gain {
+			best = &hoeffdingSplit{key: key, threshold: threshold, parts: []*classStats{left, right}, gain: gain}
+		}
+	}
+
+	return best
+}
+
+func copyCounts(counts map[interface{}]float64) map[interface{}]float64 {
+	c := make(map[interface{}]float64, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
+
+// Snapshot returns a copy of the current tree, which is not modified by the following
+// learnings. Like the trained trees, every node holds the majority label on its Content and
+// the statistics of its samples on its Metadata, and each leaf has a leaf child named after its label.
+func (h *HoeffdingTree) Snapshot() *Tree {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var nodes []Tree
+	add := func(node Tree, parentID int) int {
+		node.ID = len(nodes) + 1
+		node.ParentID = parentID
+		nodes = append(nodes, node)
+		return node.ID
+	}
+
+	var visit func(n *hoeffdingNode, node Tree, parentID int)
+	visit = func(n *hoeffdingNode, node Tree, parentID int) {
+		var content interface{}
+		if n.stats.total > 0 {
+			content = majorityOf(n.stats.counts)
+		}
+		node.Content = content
+		node.Metadata = statsMetadata(n.stats)
+		id := add(node, parentID)
+
+		if len(n.children) == 0 {
+			if content != nil {
+				add(Tree{Name: fmt.Sprint(content), Content: content, Metadata: statsMetadata(n.stats)}, id)
+			}
+			return
+		}
+
+		for i, child := range n.children {
+			visit(child, Tree{Key: child.key, Operator: child.operator, Value: child.value, Order: i + 1}, id)
+		}
+	}
+	visit(h.root, Tree{Name: "root"}, 0)
+
+	return CreateTree(nodes)
+}
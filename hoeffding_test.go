@@ -0,0 +1,122 @@
+package dtree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thresholdStream : the label is "high" when x is bigger than 50, color is noise
+func thresholdStream(seed int64, n int) []Sample {
+	random := rand.New(rand.NewSource(seed))
+	colors := []string{"red", "green", "blue"}
+	samples := make([]Sample, n)
+	for i := range samples {
+		x := random.Float64() * 100
+		label := "low"
+		if x > 50 {
+			label = "high"
+		}
+		samples[i] = Sample{
+			Request: map[string]interface{}{"x": x, "color": colors[random.Intn(len(colors))]},
+			Label:   label,
+		}
+	}
+	return samples
+}
+
+func TestHoeffdingTree_Numeric(t *testing.T) {
+	// Arrange
+	h := NewHoeffdingTree(func(o *HoeffdingOptions) {
+		o.GracePeriod = 100
+	})
+
+	// Act
+	for _, s := range thresholdStream(1, 3000) {
+		assert.NoError(t, h.Learn(s))
+	}
+	tr := h.Snapshot()
+
+	// Assert
+	assert.Equal(t, 3000, h.Samples())
+	assert.Equal(t, "x", tr.GetChild()[0].Key)
+	assert.Equal(t, 3000.0, tr.Metadata["samples"])
+
+	r, err := EvaluateClassification(tr, thresholdStream(2, 1000))
+	assert.NoError(t, err)
+	assert.True(t, r.Accuracy > 0.9, "accuracy %v", r.Accuracy)
+}
+
+func TestHoeffdingTree_Categorical(t *testing.T) {
+	// Arrange
+	h := NewHoeffdingTree(func(o *HoeffdingOptions) {
+		o.GracePeriod = 50
+	})
+	random := rand.New(rand.NewSource(3))
+	labels := map[string]string{"red": "stop", "green": "go", "orange": "stop"}
+	colors := []string{"red", "green", "orange"}
+
+	// Act
+	for i := 0; i < 600; i++ {
+		color := colors[random.Intn(len(colors))]
+		assert.NoError(t, h.Learn(Sample{
+			Request: map[string]interface{}{"light": color, "noise": random.Float64()},
+			Label:   labels[color],
+		}))
+	}
+	tr := h.Snapshot()
+
+	// Assert
+	children := tr.GetChild()
+	assert.Len(t, children, 3)
+	for _, child := range children {
+		assert.Equal(t, "light", child.Key)
+		assert.Equal(t, "eq", child.Operator)
+		node, err := tr.Resolve(map[string]interface{}{"light": child.Value, "noise": 0.5})
+		assert.NoError(t, err)
+		assert.Equal(t, labels[child.Value.(string)], Outcome(node))
+	}
+}
+
+func TestHoeffdingTree_Snapshot(t *testing.T) {
+	// Arrange
+	h := NewHoeffdingTree(func(o *HoeffdingOptions) {
+		o.GracePeriod = 100
+	})
+	stream := thresholdStream(4, 2000)
+	for _, s := range stream[:150] {
+		assert.NoError(t, h.Learn(s))
+	}
+	snapshot := h.Snapshot()
+	before := snapshot.String()
+
+	// Act
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, s := range stream[150:] {
+			h.Learn(s)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		_, err := h.Snapshot().Resolve(stream[i].Request)
+		assert.NoError(t, err)
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, before, snapshot.String(), "a snapshot is not modified by the learning")
+	assert.NotEqual(t, before, h.Snapshot().String())
+}
+
+func TestHoeffdingTree_Errors(t *testing.T) {
+	h := NewHoeffdingTree()
+	assert.Equal(t, ErrBadLabel, h.Learn(Sample{Label: []string{"a"}}))
+
+	tr := h.Snapshot()
+	assert.Equal(t, "root", tr.Name)
+	assert.Nil(t, tr.Content)
+}
@@ -0,0 +1,326 @@
+package dtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrBadModel : The imported model has not the expected structure
+var ErrBadModel = errors.New("invalid model")
+
+// ImportOptions allow to configure the import of models trained by other libraries
+type ImportOptions struct {
+	// FeatureNames are the keys of the request, by feature index. They override the names of the model,
+	// and name the XGBoost features "f0", "f1"...
+	FeatureNames []string
+	// BaseScore is the initial prediction of an XGBoost model (0.5 by default)
+	BaseScore float64
+	// Link is the link function of an XGBoost model, IdentityLink (by default) or LogisticLink
+	// (objective binary:logistic, BaseScore being then a probability)
+	Link string
+}
+
+func newImportOptions(options []func(o *ImportOptions)) *ImportOptions {
+	config := &ImportOptions{BaseScore: 0.5, Link: IdentityLink}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// sklearnTree is the structure of a fitted scikit-learn tree (tree_ attribute) exported as json
+type sklearnTree struct {
+	FeatureNames  []string      `json:"feature_names"`
+	ClassNames    []interface{} `json:"class_names"`
+	Classes       []interface{} `json:"classes"`
+	ChildrenLeft  []int         `json:"children_left"`
+	ChildrenRight []int         `json:"children_right"`
+	Feature       []int         `json:"feature"`
+	Threshold     []float64     `json:"threshold"`
+	Value         []interface{} `json:"value"`
+}
+
+// ImportSklearn builds a tree from a scikit-learn tree exported as json:
+//
+//	{
+//		"feature_names": ["petal_length", "petal_width"],
+//		"class_names": ["setosa", "versicolor", "virginica"],
+//		"children_left": [...], "children_right": [...], "feature": [...], "threshold": [...], "value": [...]
+//	}
+//
+// Each split becomes a lte and a gt node on the threshold. Every node holds the prediction of its
+// samples on its Content: the class with the biggest value for a classifier (its index if there is no
+// class names), the value itself (float64) for a regressor. Features without name are called "feature_<index>".
+// The nodes of a classifier keep the values of the classes on their "distribution" Metadata, see ResolveProba.
+func ImportSklearn(model []byte, options ...func(o *ImportOptions)) (*Tree, error) {
+	config := newImportOptions(options)
+
+	var m sklearnTree
+	if err := json.Unmarshal(model, &m); err != nil {
+		return nil, err
+	}
+	if len(config.FeatureNames) > 0 {
+		m.FeatureNames = config.FeatureNames
+	}
+	if len(m.ClassNames) == 0 {
+		m.ClassNames = m.Classes
+	}
+
+	n := len(m.ChildrenLeft)
+	if n == 0 || len(m.ChildrenRight) != n || len(m.Feature) != n || len(m.Threshold) != n || len(m.Value) != n {
+		return nil, fmt.Errorf("%v: children_left, children_right, feature, threshold and value must have the same length", ErrBadModel)
+	}
+
+	contents := make([]interface{}, n)
+	metadata := make([]map[string]interface{}, n)
+	for i, v := range m.Value {
+		values := firstOutput(v)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%v: node %d has no value", ErrBadModel, i)
+		}
+
+		switch {
+		case len(m.ClassNames) > 0 || len(values) > 1:
+			best := 0
+			for j := range values {
+				if values[j] > values[best] {
+					best = j
+				}
+			}
+			if len(m.ClassNames) > 0 {
+				if best >= len(m.ClassNames) {
+					return nil, fmt.Errorf("%v: node %d has more values than classes", ErrBadModel, i)
+				}
+				contents[i] = m.ClassNames[best]
+			} else {
+				contents[i] = float64(best)
+			}
+
+			distribution := make(map[string]interface{}, len(values))
+			for j, w := range values {
+				class := fmt.Sprint(j)
+				if j < len(m.ClassNames) {
+					class = classKey(m.ClassNames[j])
+				}
+				distribution[class] = w
+			}
+			metadata[i] = map[string]interface{}{"distribution": distribution}
+		default:
+			contents[i] = values[0]
+		}
+	}
+
+	var nodes []Tree
+	add := func(node Tree, parentID int) int {
+		node.ID = len(nodes) + 1
+		node.ParentID = parentID
+		nodes = append(nodes, node)
+		return node.ID
+	}
+
+	var visit func(i int, node Tree, parentID int, depth int) error
+	visit = func(i int, node Tree, parentID int, depth int) error {
+		if i < 0 || i >= n || depth > n {
+			return fmt.Errorf("%v: unknown node %d", ErrBadModel, i)
+		}
+
+		node.Content = contents[i]
+		node.Metadata = metadata[i]
+		id := add(node, parentID)
+
+		if m.ChildrenLeft[i] < 0 {
+			name := fmt.Sprint(contents[i])
+			if v, ok := contents[i].(float64); ok && len(m.ClassNames) == 0 {
+				name = fmt.Sprintf("%.4g", v)
+			}
+			add(Tree{Name: name, Content: contents[i]}, id)
+			return nil
+		}
+
+		key := featureName(m.FeatureNames, m.Feature[i], "feature_%d")
+		threshold := m.Threshold[i]
+		if err := visit(m.ChildrenLeft[i], Tree{Key: key, Operator: "lte", Value: threshold, Order: 1}, id, depth+1); err != nil {
+			return err
+		}
+		return visit(m.ChildrenRight[i], Tree{Key: key, Operator: "gt", Value: threshold, Order: 2}, id, depth+1)
+	}
+
+	if err := visit(0, Tree{Name: "root"}, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return CreateTree(nodes), nil
+}
+
+// firstOutput returns the values of the first output of a node ([[v...]], [v...] or v)
+func firstOutput(v interface{}) []float64 {
+	switch value := v.(type) {
+	case float64:
+		return []float64{value}
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		if _, ok := value[0].([]interface{}); ok {
+			return firstOutput(value[0])
+		}
+
+		values := make([]float64, 0, len(value))
+		for _, x := range value {
+			f, ok := x.(float64)
+			if !ok {
+				return nil
+			}
+			values = append(values, f)
+		}
+		return values
+	}
+
+	return nil
+}
+
+func featureName(names []string, index int, format string) string {
+	if index >= 0 && index < len(names) {
+		return names[index]
+	}
+	return fmt.Sprintf(format, index)
+}
+
+// xgboostNode is a node of an XGBoost json dump (booster.get_dump(dump_format="json"))
+type xgboostNode struct {
+	NodeID         int            `json:"nodeid"`
+	Split          string         `json:"split"`
+	SplitCondition *float64       `json:"split_condition"`
+	Yes            int            `json:"yes"`
+	No             int            `json:"no"`
+	Missing        int            `json:"missing"`
+	Leaf           *float64       `json:"leaf"`
+	Children       []*xgboostNode `json:"children"`
+}
+
+// ImportXGBoost builds an Ensemble from an XGBoost json dump: a json list of the trees returned by
+// booster.get_dump(dump_format="json"), as objects or as the strings themselves.
+// XGBoost goes to the "yes" branch when the value is lower than the split condition: each split
+// becomes a lt and a gte node on the condition, keeping its exact behaviour.
+// The leaves hold their value (float64) on their Content. When the key of a split is missing
+// from the request, the resolution follows the "missing" branch (the DefaultChild of the split).
+func ImportXGBoost(dump []byte, options ...func(o *ImportOptions)) (*Ensemble, error) {
+	config := newImportOptions(options)
+	if config.Link != IdentityLink && config.Link != LogisticLink {
+		return nil, fmt.Errorf("unknown link %q", config.Link)
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(dump, &raws); err != nil {
+		return nil, err
+	}
+
+	e := &Ensemble{Link: config.Link, Base: config.BaseScore}
+	if config.Link == LogisticLink {
+		if config.BaseScore <= 0 || config.BaseScore >= 1 {
+			return nil, fmt.Errorf("%v: base score %v is not a probability", ErrBadModel, config.BaseScore)
+		}
+		e.Base = math.Log(config.BaseScore / (1 - config.BaseScore))
+	}
+
+	for i, raw := range raws {
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`)) {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			raw = json.RawMessage(s)
+		}
+
+		var root xgboostNode
+		if err := json.Unmarshal(raw, &root); err != nil {
+			return nil, fmt.Errorf("tree %d: %v", i, err)
+		}
+
+		t, err := xgboostTree(&root, config)
+		if err != nil {
+			return nil, fmt.Errorf("tree %d: %v", i, err)
+		}
+		e.Trees = append(e.Trees, t)
+	}
+
+	return e, nil
+}
+
+func xgboostTree(root *xgboostNode, config *ImportOptions) (*Tree, error) {
+	var nodes []Tree
+	add := func(node Tree, parentID int) int {
+		node.ID = len(nodes) + 1
+		node.ParentID = parentID
+		nodes = append(nodes, node)
+		return node.ID
+	}
+
+	// visit adds the node and its descendants, and returns the id of the node
+	var visit func(n *xgboostNode, node Tree, parentID int) (int, error)
+	visit = func(n *xgboostNode, node Tree, parentID int) (int, error) {
+		if n.Leaf != nil {
+			node.Content = *n.Leaf
+			id := add(node, parentID)
+			add(Tree{Name: fmt.Sprintf("%+.4g", *n.Leaf), Content: *n.Leaf}, id)
+			return id, nil
+		}
+
+		if n.SplitCondition == nil {
+			return 0, fmt.Errorf("%v: node %d has no split condition (indicator splits are not supported)", ErrBadModel, n.NodeID)
+		}
+
+		var yes, no *xgboostNode
+		for _, child := range n.Children {
+			switch child.NodeID {
+			case n.Yes:
+				yes = child
+			case n.No:
+				no = child
+			}
+		}
+		if yes == nil || no == nil {
+			return 0, fmt.Errorf("%v: children of node %d not found", ErrBadModel, n.NodeID)
+		}
+
+		id := add(node, parentID)
+		key := xgboostFeature(n.Split, config.FeatureNames)
+		yesID, err := visit(yes, Tree{Key: key, Operator: "lt", Value: *n.SplitCondition, Order: 1}, id)
+		if err != nil {
+			return 0, err
+		}
+		noID, err := visit(no, Tree{Key: key, Operator: "gte", Value: *n.SplitCondition, Order: 2}, id)
+		if err != nil {
+			return 0, err
+		}
+
+		switch n.Missing {
+		case n.Yes:
+			nodes[id-1].DefaultChild = yesID
+		case n.No:
+			nodes[id-1].DefaultChild = noID
+		}
+		return id, nil
+	}
+
+	if _, err := visit(root, Tree{Name: "root"}, 0); err != nil {
+		return nil, err
+	}
+
+	return CreateTree(nodes), nil
+}
+
+// xgboostFeature returns the key of a split, "f<index>" being renamed with the feature names
+func xgboostFeature(split string, names []string) string {
+	if strings.HasPrefix(split, "f") {
+		if index, err := strconv.Atoi(split[1:]); err == nil && index >= 0 && index < len(names) {
+			return names[index]
+		}
+	}
+	return split
+}
@@ -0,0 +1,129 @@
+package dtree
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportSklearn_Classifier(t *testing.T) {
+	// Arrange
+	model, err := ioutil.ReadFile("testdata/sklearn_iris.json")
+	assert.NoError(t, err)
+
+	// Act
+	tr, err := ImportSklearn(model)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "root", tr.Name)
+	assert.Equal(t, "setosa", tr.Content)
+
+	tests := []struct {
+		request  map[string]interface{}
+		expected string
+		id       int
+	}{
+		{map[string]interface{}{"petal_length": 1.4, "petal_width": 0.2}, "setosa", 3},
+		{map[string]interface{}{"petal_length": 4.0, "petal_width": 1.3}, "versicolor", 6},
+		{map[string]interface{}{"petal_length": 5.5, "petal_width": 2.0}, "virginica", 8},
+		{map[string]interface{}{"petal_length": 2.45, "petal_width": 2.0}, "setosa", 3},
+	}
+	for _, test := range tests {
+		node, err := tr.Resolve(test.request)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, node.Content)
+		assert.Equal(t, test.expected, node.Name)
+		assert.Equal(t, test.id, node.ID)
+	}
+	assert.Contains(t, tr.String(), "petal_width lte 1.75")
+}
+
+func TestImportSklearn_Regressor(t *testing.T) {
+	// Arrange
+	model := []byte(`{
+		"children_left": [1, -1, -1],
+		"children_right": [2, -1, -1],
+		"feature": [0, -2, -2],
+		"threshold": [10.5, -2, -2],
+		"value": [[[32.5]], [[20]], [[45]]]
+	}`)
+
+	// Act
+	tr, err := ImportSklearn(model, func(o *ImportOptions) {
+		o.FeatureNames = []string{"distance"}
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	v, err := tr.Predict(map[string]interface{}{"distance": 12.0})
+	assert.NoError(t, err)
+	assert.Equal(t, 45.0, v)
+
+	v, err = tr.Predict(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, 32.5, v, "the resolution stops on the root without distance")
+}
+
+func TestImportSklearn_Errors(t *testing.T) {
+	_, err := ImportSklearn([]byte(`{"children_left": [1, -1], "children_right": [2, -1], "feature": [0, -2], "threshold": [1, -2], "value": [1, 2]}`))
+	assert.Error(t, err)
+
+	_, err = ImportSklearn([]byte(`{"children_left": [1], "children_right": [2]}`))
+	assert.Error(t, err)
+}
+
+func TestImportXGBoost(t *testing.T) {
+	// Arrange
+	dump, err := ioutil.ReadFile("testdata/xgboost_dump.json")
+	assert.NoError(t, err)
+
+	// Act
+	e, err := ImportXGBoost(dump, func(o *ImportOptions) {
+		o.FeatureNames = []string{"length", "width"}
+		o.Link = LogisticLink
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, e.Trees, 2)
+	assert.Equal(t, 0.0, e.Base)
+
+	tests := []struct {
+		request map[string]interface{}
+		score   float64
+	}{
+		{map[string]interface{}{"length": 1.0, "width": 0.0}, -0.35},
+		{map[string]interface{}{"length": 3.0, "width": 1.0}, 0.15},
+		{map[string]interface{}{"length": 5.0, "width": 1.5}, 0.55},
+		// the missing features follow the "missing" branches
+		{map[string]interface{}{"width": 2.0}, -0.35},
+		{map[string]interface{}{"length": 5.0}, 0.55},
+	}
+	for _, test := range tests {
+		x, err := e.Explain(test.request)
+		assert.NoError(t, err)
+		assert.InDelta(t, test.score, x.Score, 1e-9)
+		assert.InDelta(t, 1/(1+math.Exp(-test.score)), x.Prediction, 1e-9)
+	}
+	assert.Contains(t, e.Trees[0].String(), "width lt 1.5")
+}
+
+func TestImportXGBoost_Strings(t *testing.T) {
+	// Arrange
+	dump := []byte(`["{\"nodeid\": 0, \"split\": \"age\", \"split_condition\": 30, \"yes\": 1, \"no\": 2, \"children\": [{\"nodeid\": 1, \"leaf\": 1.5}, {\"nodeid\": 2, \"leaf\": 2.5}]}"]`)
+
+	// Act
+	e, err := ImportXGBoost(dump)
+
+	// Assert
+	assert.NoError(t, err)
+	v, err := e.Predict(map[string]interface{}{"age": 40.0})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, v)
+
+	_, err = ImportXGBoost([]byte(`[{"nodeid": 0, "split": "f0", "yes": 1, "no": 2, "children": []}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,269 @@
+package dtree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ways of measuring the importance of the keys
+const (
+	// ImportanceImpurity sums the impurity decrease of the splits on the key, weighted by their samples
+	ImportanceImpurity = "impurity"
+	// ImportanceSamples sums the samples going through the nodes on the key
+	ImportanceSamples = "samples"
+	// ImportanceNodes counts the nodes on the key
+	ImportanceNodes = "nodes"
+	// ImportanceTraffic counts the decisions made on the key while resolving requests
+	ImportanceTraffic = "traffic"
+)
+
+// KeyImportance is the importance of a key of the requests
+type KeyImportance struct {
+	Key string `json:"key"`
+	// Importance is the part of the Score of the key, the importances of all the keys sum to 1
+	Importance float64 `json:"importance"`
+	// Score is the measure of the key, according to the method
+	Score float64 `json:"score"`
+	// Nodes is the number of nodes on the key
+	Nodes int `json:"nodes"`
+}
+
+// Importance lists the keys, the most important first
+type Importance struct {
+	Method string          `json:"method"`
+	Keys   []KeyImportance `json:"keys"`
+}
+
+// isCondition returns true if the node compares a key of the request
+func isCondition(n *Tree) bool {
+	return n.Key != "" && n.Operator != "" && !isFallback(n)
+}
+
+// metadataFloat returns a number of the Metadata of a node
+func metadataFloat(n *Tree, key string) (float64, bool) {
+	v, ok := n.Metadata[key].(float64)
+	return v, ok
+}
+
+// FeatureImportance measures how much each key contributes to the decisions of the tree.
+// With the statistics of the trained trees (the "samples" and "impurity" Metadata of the root),
+// it is the impurity decrease of the splits on the key, weighted by their samples (ImportanceImpurity).
+// With only the number of samples of the nodes (an imported tree for instance), the samples going
+// through the nodes on the key (ImportanceSamples), else the number of nodes on the key (ImportanceNodes).
+func FeatureImportance(t *Tree) *Importance {
+	scores := make(map[string]float64)
+	nodes := make(map[string]int)
+
+	method := ImportanceNodes
+	if _, ok := metadataFloat(t, "impurity"); ok {
+		method = ImportanceImpurity
+	} else if _, ok := metadataFloat(t, "samples"); ok {
+		method = ImportanceSamples
+	}
+
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		groups := make(map[string][]*Tree)
+		for _, child := range n.GetChild() {
+			if isCondition(child) {
+				nodes[child.Key]++
+				groups[child.Key] = append(groups[child.Key], child)
+			}
+			visit(child)
+		}
+
+		for key, children := range groups {
+			switch method {
+			case ImportanceNodes:
+				scores[key] += float64(len(children))
+			case ImportanceSamples:
+				for _, child := range children {
+					samples, _ := metadataFloat(child, "samples")
+					scores[key] += samples
+				}
+			case ImportanceImpurity:
+				scores[key] += impurityDecrease(n, children)
+			}
+		}
+	}
+	visit(t)
+
+	return newImportance(method, scores, nodes)
+}
+
+// impurityDecrease returns the impurity of the samples of the children before and after the split
+func impurityDecrease(parent *Tree, children []*Tree) float64 {
+	impurity, ok := metadataFloat(parent, "impurity")
+	if !ok {
+		return 0
+	}
+
+	var decrease float64
+	for _, child := range children {
+		samples, ok := metadataFloat(child, "samples")
+		childImpurity, ok2 := metadataFloat(child, "impurity")
+		if !ok || !ok2 {
+			return 0
+		}
+		decrease += samples * (impurity - childImpurity)
+	}
+
+	if decrease < 0 {
+		return 0
+	}
+	return decrease
+}
+
+// TrafficImportance resolves the requests and counts, for each key, the nodes on the key
+// selected on the decision paths
+func TrafficImportance(t *Tree, requests []map[string]interface{}, options ...func(t *TreeOptions)) (*Importance, error) {
+	if t == nil {
+		return nil, ErrNoNode
+	}
+
+	scores := make(map[string]float64)
+	nodes := make(map[string]int)
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		for _, child := range n.GetChild() {
+			if isCondition(child) {
+				nodes[child.Key]++
+			}
+			visit(child)
+		}
+	}
+	visit(t)
+
+	for i, request := range requests {
+		node, err := t.Resolve(request, options...)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %v", i, err)
+		}
+		for n := node; n != nil && n != t; n = n.GetParent() {
+			if isCondition(n) {
+				scores[n.Key]++
+			}
+		}
+	}
+
+	return newImportance(ImportanceTraffic, scores, nodes), nil
+}
+
+// AnnotateStats resolves the samples and stores, in the Metadata of every node, the statistics of the
+// samples going through it: "samples", "impurity" and "distribution" (number of samples of each label),
+// as Train does. It gives them to any tree, hand-written or imported, for String and FeatureImportance.
+// The nodes no sample goes through get 0 samples. Nothing is stored if a resolution fails.
+func AnnotateStats(t *Tree, samples []Sample, options ...func(t *TreeOptions)) error {
+	if t == nil {
+		return ErrNoNode
+	}
+	if len(samples) == 0 {
+		return ErrNoSample
+	}
+
+	stats := make(map[*Tree]targetStats)
+	t.Walk(func(n *Tree) error {
+		stats[n] = classification{}.newStats()
+		return nil
+	})
+
+	for i, s := range samples {
+		node, err := t.Resolve(s.Request, options...)
+		if err != nil {
+			return fmt.Errorf("sample %d: %v", i, err)
+		}
+
+		// the labels are compared as the outcomes are, json values included
+		row := trainRow{request: s.Request, label: classKey(s.Label), weight: 1}
+		for n := node; n != nil; n = n.GetParent() {
+			stats[n].add(row, 1)
+			if n == t {
+				break
+			}
+		}
+	}
+
+	for n, s := range stats {
+		if n.Metadata == nil {
+			n.Metadata = make(map[string]interface{})
+		}
+		for k, v := range statsMetadata(s) {
+			n.Metadata[k] = v
+		}
+	}
+
+	return nil
+}
+
+func newImportance(method string, scores map[string]float64, nodes map[string]int) *Importance {
+	var total float64
+	for _, s := range scores {
+		total += s
+	}
+
+	im := &Importance{Method: method}
+	for key := range nodes {
+		k := KeyImportance{Key: key, Score: scores[key], Nodes: nodes[key]}
+		if total > 0 {
+			k.Importance = k.Score / total
+		}
+		im.Keys = append(im.Keys, k)
+	}
+	sortImportance(im.Keys)
+
+	return im
+}
+
+func sortImportance(keys []KeyImportance) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Importance != keys[j].Importance {
+			return keys[i].Importance > keys[j].Importance
+		}
+		return keys[i].Key < keys[j].Key
+	})
+}
+
+// FeatureImportance returns the mean importance of each key on the trees of the forest
+func (f *Forest) FeatureImportance() *Importance {
+	im := &Importance{}
+	byKey := make(map[string]*KeyImportance)
+	for _, t := range f.Trees {
+		ti := FeatureImportance(t)
+		if im.Method == "" {
+			im.Method = ti.Method
+		}
+		for _, k := range ti.Keys {
+			if _, ok := byKey[k.Key]; !ok {
+				byKey[k.Key] = &KeyImportance{Key: k.Key}
+			}
+			byKey[k.Key].Importance += k.Importance / float64(len(f.Trees))
+			byKey[k.Key].Score += k.Score / float64(len(f.Trees))
+			byKey[k.Key].Nodes += k.Nodes
+		}
+	}
+
+	for _, k := range byKey {
+		im.Keys = append(im.Keys, *k)
+	}
+	sortImportance(im.Keys)
+
+	return im
+}
+
+// String writes one line per key, the most important first
+func (im *Importance) String() string {
+	width := len("key")
+	for _, k := range im.Keys {
+		if len(k.Key) > width {
+			width = len(k.Key)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "importance (%s)\n", im.Method)
+	for _, k := range im.Keys {
+		fmt.Fprintf(&b, "%-*s %6.2f%% (%d nodes)\n", width, k.Key, k.Importance*100, k.Nodes)
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package dtree
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureImportance_Trained(t *testing.T) {
+	// Arrange
+	tr, err := Train(loadSamples(t, "testdata/weather.json"))
+	assert.NoError(t, err)
+
+	// Act
+	im := FeatureImportance(tr)
+
+	// Assert
+	assert.Equal(t, ImportanceImpurity, im.Method)
+	// outlook decreases the entropy of the 14 samples (0.940) to 0.694, humidity and windy each
+	// one take 5 samples with an entropy of 0.971 to 0
+	assert.Equal(t, []string{"humidity", "windy", "outlook"}, []string{im.Keys[0].Key, im.Keys[1].Key, im.Keys[2].Key})
+	assert.InDelta(t, 14*0.9403-10*0.9710, im.Keys[2].Score, 1e-3)
+	assert.InDelta(t, 5*0.9710, im.Keys[0].Score, 1e-3)
+	var sum float64
+	for _, k := range im.Keys {
+		sum += k.Importance
+		assert.True(t, k.Nodes > 0)
+	}
+	assert.InDelta(t, 1, sum, 1e-9)
+
+	assert.Equal(t, 14.0, tr.Metadata["samples"])
+	assert.Equal(t, map[string]interface{}{"yes": 9.0, "no": 5.0}, tr.Metadata["distribution"])
+	assert.Contains(t, tr.String(), "samples 14: yes 9, no 5")
+}
+
+func TestFeatureImportance_HandWritten(t *testing.T) {
+	// Arrange
+	b, err := ioutil.ReadFile("testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := LoadTree(b)
+	assert.NoError(t, err)
+
+	// Act
+	im := FeatureImportance(tr)
+
+	// Assert
+	assert.Equal(t, ImportanceNodes, im.Method)
+	assert.Equal(t, []KeyImportance{
+		{Key: "age", Importance: 1.0 / 3, Score: 2, Nodes: 2},
+		{Key: "gender", Importance: 1.0 / 3, Score: 2, Nodes: 2},
+		{Key: "sayHello", Importance: 1.0 / 3, Score: 2, Nodes: 2},
+	}, im.Keys)
+}
+
+func TestFeatureImportance_Samples(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root", Metadata: map[string]interface{}{"samples": 10.0}},
+		{ID: 2, ParentID: 1, Key: "country", Operator: "eq", Value: "fr", Order: 1, Metadata: map[string]interface{}{"samples": 8.0}},
+		{ID: 3, ParentID: 2, Key: "age", Operator: "gt", Value: 18.0, Metadata: map[string]interface{}{"samples": 2.0}},
+		{ID: 4, ParentID: 1, Value: "fallback", Order: 2, Metadata: map[string]interface{}{"samples": 2.0}},
+	})
+
+	// Act
+	im := FeatureImportance(tr)
+
+	// Assert
+	assert.Equal(t, ImportanceSamples, im.Method)
+	assert.Equal(t, "country", im.Keys[0].Key)
+	assert.Equal(t, 0.8, im.Keys[0].Importance)
+	assert.Equal(t, 0.2, im.Keys[1].Importance)
+	assert.Contains(t, tr.String(), "samples 8")
+}
+
+func TestTrafficImportance(t *testing.T) {
+	// Arrange
+	b, err := ioutil.ReadFile("testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := LoadTree(b)
+	assert.NoError(t, err)
+	requests := []map[string]interface{}{
+		{"sayHello": false},
+		{"sayHello": false},
+		{"sayHello": true, "gender": "M", "age": 70.0},
+	}
+
+	// Act
+	im, err := TrafficImportance(tr, requests)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ImportanceTraffic, im.Method)
+	assert.Equal(t, []KeyImportance{
+		{Key: "sayHello", Importance: 0.6, Score: 3, Nodes: 2},
+		{Key: "age", Importance: 0.2, Score: 1, Nodes: 2},
+		{Key: "gender", Importance: 0.2, Score: 1, Nodes: 2},
+	}, im.Keys)
+	assert.Contains(t, im.String(), "sayHello  60.00% (2 nodes)")
+}
+
+func TestForest_FeatureImportance(t *testing.T) {
+	// Arrange
+	f, err := TrainForest(loadSamples(t, "testdata/weather.json"), func(o *ForestOptions) {
+		o.NumTrees = 5
+		o.MaxFeatures = 4
+		o.Seed = 1
+	})
+	assert.NoError(t, err)
+
+	// Act
+	im := f.FeatureImportance()
+
+	// Assert
+	assert.Equal(t, ImportanceImpurity, im.Method)
+	var sum float64
+	for _, k := range im.Keys {
+		sum += k.Importance
+	}
+	assert.InDelta(t, 1, sum, 1e-9)
+}
+
+func TestAnnotateStats(t *testing.T) {
+	// Arrange
+	b, err := ioutil.ReadFile("testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := LoadTree(b)
+	assert.NoError(t, err)
+	samples := []Sample{
+		{Request: map[string]interface{}{"sayHello": false}, Label: "bye"},
+		{Request: map[string]interface{}{"sayHello": true, "gender": "F"}, Label: "hi"},
+		{Request: map[string]interface{}{"sayHello": true, "gender": "M", "age": 70.0}, Label: "hi"},
+		{Request: map[string]interface{}{"sayHello": true}, Label: "hello"},
+	}
+
+	// Act
+	err = AnnotateStats(tr, samples)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, tr.Metadata["samples"])
+	assert.Equal(t, map[string]interface{}{"hi": 2.0, "hello": 1.0}, tr.FindByID(2).Metadata["distribution"])
+	assert.Equal(t, 1.0, tr.FindByID(11).Metadata["samples"])
+	assert.Equal(t, 0.0, tr.FindByID(13).Metadata["samples"], "no sample goes through the node")
+	assert.Contains(t, tr.String(), "samples 4: hi 2, bye 1, hello 1")
+	assert.Contains(t, tr.String(), "samples 3: hi 2, hello 1")
+	assert.Equal(t, ImportanceImpurity, FeatureImportance(tr).Method)
+
+	assert.Error(t, AnnotateStats(tr, []Sample{{Request: map[string]interface{}{"sayHello": "yes"}}}, func(o *TreeOptions) {
+		o.StopIfConvertingError = true
+	}))
+	assert.Equal(t, 4.0, tr.Metadata["samples"], "the statistics are kept when a resolution fails")
+}
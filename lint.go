@@ -0,0 +1,490 @@
+package dtree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Lint rules
+const (
+	// LintUnreachable : an earlier sibling on the same key already matches every value of this node
+	LintUnreachable = "unreachable"
+	// LintRangeGap : numeric ranges of the siblings leave values that no sibling matches, and there is no fallback
+	LintRangeGap = "range-gap"
+	// LintMultipleFallback : more than one fallback under the same parent
+	LintMultipleFallback = "multiple-fallback"
+	// LintPercentOverflow : the percent (or ab) weights of the siblings are over 100
+	LintPercentOverflow = "percent-overflow"
+	// LintAfterFallback : a node is evaluated after a sibling that always matches
+	LintAfterFallback = "after-fallback"
+	// LintKeyType : the same key is compared with values of different types across the tree
+	LintKeyType = "key-type"
+	// LintDefaultChild : the default child of a node is not one of its children
+	LintDefaultChild = "default-child"
+)
+
+// LintIssue is a logical problem found on a tree
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	NodeIDs []int  `json:"node_ids"`
+	Message string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	ids := make([]string, len(i.NodeIDs))
+	for k, id := range i.NodeIDs {
+		ids[k] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("%s [%s] %s", i.Rule, strings.Join(ids, ","), i.Message)
+}
+
+// Lint looks for logical problems on the tree: nodes that can never be selected,
+// numeric gaps without fallback, percent weights over 100, keys used with different types ...
+func Lint(t *Tree) []LintIssue {
+	if t == nil {
+		return nil
+	}
+
+	var issues []LintIssue
+	lintNode(t, &issues)
+	issues = append(issues, lintKeyTypes(t)...)
+
+	return issues
+}
+
+func lintNode(t *Tree, issues *[]LintIssue) {
+	siblings := t.GetChild()
+
+	var fallbacks []int
+	var catchAll *Tree
+	for i, n := range siblings {
+		if catchAll != nil {
+			*issues = append(*issues, LintIssue{
+				Rule:    LintAfterFallback,
+				NodeIDs: []int{catchAll.ID, n.ID},
+				Message: fmt.Sprintf("node %d is never evaluated, node %d before it always matches", n.ID, catchAll.ID),
+			})
+			if isFallback(n) {
+				fallbacks = append(fallbacks, n.ID)
+			}
+			continue
+		}
+
+		if isFallback(n) || n.Operator == "" {
+			if isFallback(n) {
+				fallbacks = append(fallbacks, n.ID)
+			}
+			catchAll = n
+			continue
+		}
+
+		for _, previous := range siblings[:i] {
+			if previous.Key == n.Key && covers(previous, n) {
+				*issues = append(*issues, LintIssue{
+					Rule:    LintUnreachable,
+					NodeIDs: []int{previous.ID, n.ID},
+					Message: fmt.Sprintf("node %d (%s %s %v) can never match, node %d (%s %s %v) is evaluated before and matches the same values", n.ID, n.Key, n.Operator, n.Value, previous.ID, previous.Key, previous.Operator, previous.Value),
+				})
+				break
+			}
+		}
+	}
+
+	if len(fallbacks) > 1 {
+		sort.Ints(fallbacks)
+		*issues = append(*issues, LintIssue{
+			Rule:    LintMultipleFallback,
+			NodeIDs: fallbacks,
+			Message: fmt.Sprintf("node %d has %d fallbacks, only the first one can be selected", t.ID, len(fallbacks)),
+		})
+	}
+
+	if t.DefaultChild != 0 && t.defaultChild() == nil {
+		*issues = append(*issues, LintIssue{
+			Rule:    LintDefaultChild,
+			NodeIDs: []int{t.ID},
+			Message: fmt.Sprintf("node %d follows node %d when a key is missing, which is not one of its children", t.ID, t.DefaultChild),
+		})
+	}
+
+	lintPercent(t, []string{"percent", "%"}, issues)
+	lintPercent(t, []string{"ab"}, issues)
+
+	if catchAll == nil {
+		lintRanges(t, issues)
+	}
+
+	for _, n := range siblings {
+		lintNode(n, issues)
+	}
+}
+
+func lintPercent(t *Tree, operators []string, issues *[]LintIssue) {
+	var ids []int
+	var total float64
+	for _, n := range t.GetChild() {
+		for _, op := range operators {
+			if n.Operator == op {
+				if v, ok := n.Value.(float64); ok {
+					ids = append(ids, n.ID)
+					total += v
+				}
+			}
+		}
+	}
+
+	if total > 100 {
+		sort.Ints(ids)
+		*issues = append(*issues, LintIssue{
+			Rule:    LintPercentOverflow,
+			NodeIDs: ids,
+			Message: fmt.Sprintf("%s weights under node %d sum to %v (over 100)", operators[0], t.ID, total),
+		})
+	}
+}
+
+// interval is a numeric range, with open or closed bounds
+type interval struct {
+	lo, hi             float64
+	loClosed, hiClosed bool
+}
+
+func nodeInterval(n *Tree) (interval, bool) {
+	v, ok := n.Value.(float64)
+	if !ok {
+		return interval{}, false
+	}
+
+	switch canonicalOperator(n.Operator) {
+	case "gt":
+		return interval{lo: v, hi: math.Inf(1)}, true
+	case "gte":
+		return interval{lo: v, hi: math.Inf(1), loClosed: true}, true
+	case "lt":
+		return interval{lo: math.Inf(-1), hi: v}, true
+	case "lte":
+		return interval{lo: math.Inf(-1), hi: v, hiClosed: true}, true
+	case "eq":
+		return interval{lo: v, hi: v, loClosed: true, hiClosed: true}, true
+	}
+
+	return interval{}, false
+}
+
+// lintRanges checks that numeric siblings on a key cover every number
+func lintRanges(t *Tree, issues *[]LintIssue) {
+	var keys []string
+	byKey := make(map[string][]*Tree)
+	for _, n := range t.GetChild() {
+		switch canonicalOperator(n.Operator) {
+		case "gt", "gte", "lt", "lte", "eq":
+			if _, ok := n.Value.(float64); ok {
+				if _, ok := byKey[n.Key]; !ok {
+					keys = append(keys, n.Key)
+				}
+				byKey[n.Key] = append(byKey[n.Key], n)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		nodes := byKey[key]
+		var ranges []interval
+		var ids []int
+		hasRange := false
+		for _, n := range nodes {
+			r, _ := nodeInterval(n)
+			ranges = append(ranges, r)
+			ids = append(ids, n.ID)
+			if canonicalOperator(n.Operator) != "eq" {
+				hasRange = true
+			}
+		}
+
+		if !hasRange {
+			continue
+		}
+
+		if gap, ok := findGap(ranges); ok {
+			sort.Ints(ids)
+			*issues = append(*issues, LintIssue{
+				Rule:    LintRangeGap,
+				NodeIDs: ids,
+				Message: fmt.Sprintf("%s %s under node %d is not matched by any sibling and there is no fallback", key, gap, t.ID),
+			})
+		}
+	}
+}
+
+// findGap returns a description of the first part of the real line not covered by the ranges
+func findGap(ranges []interval) (string, bool) {
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].lo != ranges[j].lo {
+			return ranges[i].lo < ranges[j].lo
+		}
+		return ranges[i].loClosed && !ranges[j].loClosed
+	})
+
+	cur := math.Inf(-1)
+	curClosed := false
+	for i, r := range ranges {
+		if i == 0 && !math.IsInf(r.lo, -1) {
+			if r.loClosed {
+				return fmt.Sprintf("lt %v", r.lo), true
+			}
+			return fmt.Sprintf("lte %v", r.lo), true
+		}
+
+		if r.lo > cur {
+			return describeGap(cur, curClosed, r.lo, r.loClosed), true
+		}
+		if r.lo == cur && !curClosed && !r.loClosed && !math.IsInf(cur, -1) {
+			return fmt.Sprintf("eq %v", cur), true
+		}
+
+		if r.hi > cur || (r.hi == cur && r.hiClosed) {
+			cur = r.hi
+			curClosed = r.hiClosed
+		}
+	}
+
+	if !math.IsInf(cur, 1) {
+		if curClosed {
+			return fmt.Sprintf("gt %v", cur), true
+		}
+		return fmt.Sprintf("gte %v", cur), true
+	}
+
+	return "", false
+}
+
+func describeGap(from float64, fromClosed bool, to float64, toClosed bool) string {
+	low := "gte"
+	if fromClosed {
+		low = "gt"
+	}
+	high := "lte"
+	if toClosed {
+		high = "lt"
+	}
+	return fmt.Sprintf("%s %v and %s %v", low, from, high, to)
+}
+
+// covers returns true if every value matched by n is also matched by previous (both on the same key)
+func covers(previous, n *Tree) bool {
+	pop, nop := canonicalOperator(previous.Operator), canonicalOperator(n.Operator)
+
+	switch nop {
+	case "eq":
+		for _, v := range valuesOf(n.Value) {
+			if !matchesValue(pop, previous.Value, v) {
+				return false
+			}
+		}
+		return len(valuesOf(n.Value)) > 0
+	case "ne":
+		return pop == "ne" && sameValue(previous.Value, n.Value)
+	case "gt", "gte", "lt", "lte":
+		return coversRange(pop, previous.Value, nop, n.Value)
+	}
+
+	return false
+}
+
+// matchesValue returns true if the scalar v is matched by the condition (op, value)
+func matchesValue(op string, value, v interface{}) bool {
+	switch op {
+	case "eq":
+		for _, pv := range valuesOf(value) {
+			if pv == v {
+				return true
+			}
+		}
+	case "gt", "gte", "lt", "lte":
+		c, ok := compareScalar(v, value)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "gt":
+			return c > 0
+		case "gte":
+			return c >= 0
+		case "lt":
+			return c < 0
+		case "lte":
+			return c <= 0
+		}
+	}
+
+	return false
+}
+
+// coversRange returns true if the range (pop, pv) contains the range (nop, nv)
+func coversRange(pop string, pv interface{}, nop string, nv interface{}) bool {
+	c, ok := compareScalar(nv, pv)
+	if !ok {
+		return false
+	}
+
+	switch pop {
+	case "gt":
+		return (nop == "gt" && c >= 0) || (nop == "gte" && c > 0)
+	case "gte":
+		return (nop == "gt" || nop == "gte") && c >= 0
+	case "lt":
+		return (nop == "lt" && c <= 0) || (nop == "lte" && c < 0)
+	case "lte":
+		return (nop == "lt" || nop == "lte") && c <= 0
+	}
+
+	return false
+}
+
+// compareScalar compares two float64 or two strings
+func compareScalar(a, b interface{}) (int, bool) {
+	switch ta := a.(type) {
+	case float64:
+		if tb, ok := b.(float64); ok {
+			switch {
+			case ta < tb:
+				return -1, true
+			case ta > tb:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if tb, ok := b.(string); ok {
+			return strings.Compare(ta, tb), true
+		}
+	}
+
+	return 0, false
+}
+
+func valuesOf(v interface{}) []interface{} {
+	switch tv := v.(type) {
+	case []interface{}:
+		return tv
+	case float64, string, bool:
+		return []interface{}{tv}
+	}
+	return nil
+}
+
+func sameValue(a, b interface{}) bool {
+	va, vb := valuesOf(a), valuesOf(b)
+	if va == nil || len(va) != len(vb) {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isFallback(n *Tree) bool {
+	s, ok := n.Value.(string)
+	return ok && s == FallbackType
+}
+
+// canonicalOperator returns the long name of an operator (== becomes eq)
+func canonicalOperator(op string) string {
+	switch op {
+	case "==":
+		return "eq"
+	case "!=":
+		return "ne"
+	case ">":
+		return "gt"
+	case "<":
+		return "lt"
+	case ">=":
+		return "gte"
+	case "<=":
+		return "lte"
+	case "%":
+		return "percent"
+	}
+	return op
+}
+
+// valueKind returns the type of request value expected by a node, "" if it cannot be known
+func valueKind(n *Tree) string {
+	switch canonicalOperator(n.Operator) {
+	case "contains", "regexp":
+		return "string"
+	case "count":
+		return "array"
+	case "eq", "ne", "gt", "gte", "lt", "lte":
+		v := n.Value
+		if values, ok := v.([]interface{}); ok && len(values) > 0 {
+			v = values[0]
+		}
+		switch v.(type) {
+		case float64:
+			return "number"
+		case string:
+			return "string"
+		case bool:
+			return "bool"
+		}
+	}
+
+	return ""
+}
+
+// lintKeyTypes checks that a key is always compared with the same type of value
+func lintKeyTypes(t *Tree) []LintIssue {
+	var keys []string
+	kinds := make(map[string]map[string][]int)
+
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		if kind := valueKind(n); kind != "" && !isFallback(n) {
+			if _, ok := kinds[n.Key]; !ok {
+				kinds[n.Key] = make(map[string][]int)
+				keys = append(keys, n.Key)
+			}
+			kinds[n.Key][kind] = append(kinds[n.Key][kind], n.ID)
+		}
+		for _, c := range n.GetChild() {
+			visit(c)
+		}
+	}
+	visit(t)
+
+	var issues []LintIssue
+	for _, key := range keys {
+		if len(kinds[key]) < 2 {
+			continue
+		}
+
+		var names []string
+		for kind := range kinds[key] {
+			names = append(names, kind)
+		}
+		sort.Strings(names)
+
+		var ids []int
+		var details []string
+		for _, kind := range names {
+			ids = append(ids, kinds[key][kind]...)
+			details = append(details, fmt.Sprintf("%s on %v", kind, kinds[key][kind]))
+		}
+		sort.Ints(ids)
+
+		issues = append(issues, LintIssue{
+			Rule:    LintKeyType,
+			NodeIDs: ids,
+			Message: fmt.Sprintf("key %q is compared with different types: %s", key, strings.Join(details, ", ")),
+		})
+	}
+
+	return issues
+}
@@ -0,0 +1,157 @@
+package dtree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintRules(issues []LintIssue) map[string][]int {
+	rules := make(map[string][]int)
+	for _, i := range issues {
+		rules[i.Rule] = i.NodeIDs
+	}
+	return rules
+}
+
+func TestLint_Clean_Tree(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+
+	// Act
+	issues := Lint(tr)
+
+	// Assert
+	assert.Empty(t, issues)
+}
+
+var linttt = []struct {
+	tree    string
+	rule    string
+	ids     []int
+	message string
+}{
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"age","operator":"gt","value":10,"order":1},
+			{"id":3,"parent_id":1,"key":"age","operator":"gt","value":20,"order":2},
+			{"id":4,"parent_id":1,"value":"fallback"}]`,
+		rule:    LintUnreachable,
+		ids:     []int{2, 3},
+		message: "Lint should detect a range included in a previous range",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"gender","operator":"eq","value":["M","F"],"order":1},
+			{"id":3,"parent_id":1,"key":"gender","operator":"==","value":"F","order":2},
+			{"id":4,"parent_id":1,"value":"fallback"}]`,
+		rule:    LintUnreachable,
+		ids:     []int{2, 3},
+		message: "Lint should detect an equality already matched by a previous equality",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"age","operator":"gte","value":18,"order":1},
+			{"id":3,"parent_id":1,"key":"age","operator":"eq","value":30,"order":2},
+			{"id":4,"parent_id":1,"value":"fallback"}]`,
+		rule:    LintUnreachable,
+		ids:     []int{2, 3},
+		message: "Lint should detect an equality inside a previous range",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"age","operator":"lt","value":10},
+			{"id":3,"parent_id":1,"key":"age","operator":"gt","value":10}]`,
+		rule:    LintRangeGap,
+		ids:     []int{2, 3},
+		message: "Lint should detect a gap between numeric ranges without fallback",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"age","operator":"gte","value":10}]`,
+		rule:    LintRangeGap,
+		ids:     []int{2},
+		message: "Lint should detect an open range without fallback",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"a","operator":"eq","value":"x"},
+			{"id":3,"parent_id":1,"value":"fallback"},
+			{"id":4,"parent_id":1,"value":"fallback"}]`,
+		rule:    LintMultipleFallback,
+		ids:     []int{3, 4},
+		message: "Lint should detect more than one fallback",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"operator":"percent","value":60},
+			{"id":3,"parent_id":1,"operator":"%","value":50}]`,
+		rule:    LintPercentOverflow,
+		ids:     []int{2, 3},
+		message: "Lint should detect percent over 100",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"name":"always","order":1},
+			{"id":3,"parent_id":1,"key":"a","operator":"eq","value":"x","order":2}]`,
+		rule:    LintAfterFallback,
+		ids:     []int{2, 3},
+		message: "Lint should detect a node after a node without operator",
+	},
+	{
+		tree: `[{"id":1},
+			{"id":2,"parent_id":1,"key":"a","operator":"eq","value":"x"},
+			{"id":3,"parent_id":2,"key":"a","operator":"gt","value":3},
+			{"id":4,"parent_id":1,"value":"fallback"},
+			{"id":5,"parent_id":2,"value":"fallback"}]`,
+		rule:    LintKeyType,
+		ids:     []int{2, 3},
+		message: "Lint should detect a key compared with different types",
+	},
+	{
+		tree: `[{"id":1,"default_child":4},
+			{"id":2,"parent_id":1,"key":"a","operator":"eq","value":"x"},
+			{"id":3,"parent_id":1,"value":"fallback"}]`,
+		rule:    LintDefaultChild,
+		ids:     []int{1},
+		message: "Lint should detect a default child which is not a child",
+	},
+}
+
+func TestLint(t *testing.T) {
+	for _, tt := range linttt {
+		// Arrange
+		tr, err := LoadTree([]byte(tt.tree))
+		assert.NoError(t, err, tt.message)
+
+		// Act
+		rules := lintRules(Lint(tr))
+
+		// Assert
+		assert.Contains(t, rules, tt.rule, tt.message)
+		assert.Equal(t, tt.ids, rules[tt.rule], tt.message)
+	}
+}
+
+func TestLint_Gap_Description(t *testing.T) {
+	var gaptt = []struct {
+		ranges []interval
+		gap    string
+	}{
+		{ranges: []interval{{lo: math.Inf(-1), hi: 10}, {lo: 10, hi: math.Inf(1)}}, gap: "eq 10"},
+		{ranges: []interval{{lo: math.Inf(-1), hi: 10}, {lo: 20, hi: math.Inf(1)}}, gap: "gte 10 and lte 20"},
+		{ranges: []interval{{lo: math.Inf(-1), hi: 10, hiClosed: true}}, gap: "gt 10"},
+		{ranges: []interval{{lo: 5, hi: math.Inf(1), loClosed: true}}, gap: "lt 5"},
+	}
+
+	for _, tt := range gaptt {
+		gap, ok := findGap(tt.ranges)
+		assert.True(t, ok)
+		assert.Equal(t, tt.gap, gap)
+	}
+
+	_, ok := findGap([]interval{{lo: math.Inf(-1), hi: 10, hiClosed: true}, {lo: 10, hi: math.Inf(1)}})
+	assert.False(t, ok, "lte 10 and gt 10 should cover every number")
+}
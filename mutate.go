@@ -0,0 +1,184 @@
+package dtree
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNotChild : the node is not a child of this one
+var ErrNotChild = errors.New("node is not a child of this one")
+
+// ErrIllegalMove : a node cannot be moved under itself or one of its descendants
+var ErrIllegalMove = errors.New("node cannot be moved under itself or one of its descendants")
+
+// CloneOptions allow to configure Clone
+type CloneOptions struct {
+	// FirstID is the id given to the copy of the node, its descendants getting the next ones
+	// (depth first). The ids are kept when it is 0.
+	FirstID int
+}
+
+// RemoveNode detaches the child node (and its descendants) from the Tree
+func (t *Tree) RemoveNode(node *Tree) error {
+	if node == nil {
+		return ErrNoNode
+	}
+
+	for i, child := range t.nodes {
+		if child != node {
+			continue
+		}
+
+		t.nodes = append(t.nodes[:i:i], t.nodes[i+1:]...)
+		if t.DefaultChild == node.ID {
+			t.DefaultChild = 0
+		}
+		node.parent = nil
+		node.ParentID = 0
+		unindexTree(node)
+		return nil
+	}
+
+	return ErrNotChild
+}
+
+// MoveNode moves the node (and its descendants) from its parent to this Tree
+func (t *Tree) MoveNode(node *Tree) error {
+	if node == nil {
+		return ErrNoNode
+	}
+
+	for n := t; n != nil; n = n.parent {
+		if n == node {
+			return ErrIllegalMove
+		}
+	}
+
+	if node.parent != nil {
+		if err := node.parent.RemoveNode(node); err != nil {
+			return err
+		}
+	}
+
+	node.ParentID = t.ID
+	t.AddNode(node)
+	return nil
+}
+
+// ReplaceNode replaces the child old by the node (with its descendants), which is detached from its
+// previous parent. The Tree follows the node by default if it followed old.
+func (t *Tree) ReplaceNode(old *Tree, node *Tree) error {
+	if old == nil || node == nil {
+		return ErrNoNode
+	}
+	if old == node {
+		return nil
+	}
+	if old.parent != t {
+		return ErrNotChild
+	}
+	for n := t; n != nil; n = n.parent {
+		if n == node {
+			return ErrIllegalMove
+		}
+	}
+
+	defaultChild := t.DefaultChild == old.ID
+	if err := t.MoveNode(node); err != nil {
+		return err
+	}
+	if err := t.RemoveNode(old); err != nil {
+		return err
+	}
+	if defaultChild {
+		t.DefaultChild = node.ID
+	}
+
+	return nil
+}
+
+// UpdateCondition changes the key, the operator and the value compared by the node, and its place
+// among its siblings (a fallback is always the last). The operator must be a known one, or one of
+// the options, or empty for a node which always matches.
+func (t *Tree) UpdateCondition(key string, operator string, value interface{}, options ...func(t *TreeOptions)) error {
+	config := newTreeOptions(&TreeOptions{}, options...)
+	if _, ok := config.Operators[operator]; operator != "" && !ok && !isExistingOperator(operator) {
+		return ErrOperator
+	}
+
+	t.Key = key
+	t.Operator = operator
+	t.Value = value
+
+	if t.parent != nil {
+		sort.Sort(byOrder(t.parent.nodes))
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the node and its descendants, detached from its parent.
+// The values, contents, headers and metadata are copied too.
+func (t *Tree) Clone(options ...func(o *CloneOptions)) *Tree {
+	config := &CloneOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	nodes := t.Flatten()
+
+	ids := make(map[int]int, len(nodes))
+	for i := range nodes {
+		ids[nodes[i].ID] = nodes[i].ID
+		if config.FirstID != 0 {
+			ids[nodes[i].ID] = config.FirstID + i
+		}
+	}
+
+	for i := range nodes {
+		n := &nodes[i]
+		n.ID = ids[n.ID]
+		if i > 0 {
+			n.ParentID = ids[n.ParentID]
+		}
+		if n.DefaultChild != 0 {
+			n.DefaultChild = ids[n.DefaultChild]
+		}
+
+		n.Value = copyValue(n.Value)
+		n.Content = copyValue(n.Content)
+		if n.Headers != nil {
+			n.Headers = copyValue(n.Headers).(map[string]interface{})
+		}
+		if n.Metadata != nil {
+			n.Metadata = copyValue(n.Metadata).(map[string]interface{})
+		}
+	}
+
+	return CreateTree(nodes)
+}
+
+// copyValue returns a deep copy of the json like values (maps and slices)
+func copyValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, x := range value {
+			m[k] = copyValue(x)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(value))
+		for i, x := range value {
+			s[i] = copyValue(x)
+		}
+		return s
+	case map[string]float64:
+		m := make(map[string]float64, len(value))
+		for k, x := range value {
+			m[k] = x
+		}
+		return m
+	}
+
+	return v
+}
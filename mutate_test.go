@@ -0,0 +1,133 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAgeTree() *Tree {
+	return CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Key: "age", Operator: "gte", Value: 18.0, Order: 1, Name: "adult"},
+		{ID: 3, ParentID: 2, Key: "country", Operator: "eq", Value: "US", Order: 1, Name: "us"},
+		{ID: 4, ParentID: 2, Value: FallbackType, Name: "other"},
+		{ID: 5, ParentID: 1, Value: FallbackType, Name: "child"},
+	})
+}
+
+func TestTree_RemoveNode(t *testing.T) {
+	// Arrange
+	tr := newAgeTree()
+	adult := tr.GetChild()[0]
+
+	// Act
+	err := tr.RemoveNode(adult)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, tr.GetChild(), 1)
+	assert.Nil(t, adult.GetParent())
+	assert.Equal(t, 0, adult.ParentID)
+	assert.Len(t, adult.GetChild(), 2, "the branch is kept on the removed node")
+
+	node, _ := tr.Resolve(map[string]interface{}{"age": 30.0})
+	assert.Equal(t, "child", node.Name)
+
+	assert.Equal(t, ErrNotChild, tr.RemoveNode(adult))
+	assert.Equal(t, ErrNoNode, tr.RemoveNode(nil))
+}
+
+func TestTree_MoveNode(t *testing.T) {
+	// Arrange
+	tr := newAgeTree()
+	adult := tr.GetChild()[0]
+	us := adult.GetChild()[0]
+
+	// Act
+	err := tr.MoveNode(us)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, tr, us.GetParent())
+	assert.Equal(t, 1, us.ParentID)
+	assert.Len(t, adult.GetChild(), 1)
+	assert.Equal(t, []string{"adult", "us", "child"}, []string{tr.GetChild()[0].Name, tr.GetChild()[1].Name, tr.GetChild()[2].Name}, "the fallback stays the last")
+
+	assert.Equal(t, ErrIllegalMove, adult.GetChild()[0].MoveNode(adult))
+	assert.Equal(t, ErrIllegalMove, adult.MoveNode(adult))
+	assert.Equal(t, ErrIllegalMove, adult.MoveNode(tr))
+}
+
+func TestTree_ReplaceNode(t *testing.T) {
+	// Arrange
+	tr := newAgeTree()
+	tr.DefaultChild = 2
+	adult := tr.GetChild()[0]
+	us := adult.GetChild()[0]
+
+	// Act
+	err := tr.ReplaceNode(adult, us)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []*Tree{us, tr.GetChild()[1]}, tr.GetChild())
+	assert.Equal(t, 3, tr.DefaultChild)
+	assert.Nil(t, adult.GetParent())
+	assert.Equal(t, ErrNotChild, tr.ReplaceNode(adult, us))
+}
+
+func TestTree_UpdateCondition(t *testing.T) {
+	// Arrange
+	tr := newAgeTree()
+	adult := tr.GetChild()[0]
+
+	// Act
+	err := adult.UpdateCondition("age", "gte", 21.0)
+
+	// Assert
+	assert.NoError(t, err)
+	node, _ := tr.Resolve(map[string]interface{}{"age": 19.0})
+	assert.Equal(t, "child", node.Name)
+
+	assert.NoError(t, tr.GetChild()[1].UpdateCondition("age", "lt", 21.0))
+	assert.Equal(t, "child", tr.GetChild()[0].Name, "siblings are sorted again, without fallback the child node comes first")
+
+	assert.Equal(t, ErrOperator, adult.UpdateCondition("age", "between", 21.0))
+	assert.NoError(t, adult.UpdateCondition("age", "between", 21.0, func(t *TreeOptions) {
+		t.Operators = map[string]Operator{"between": nil}
+	}))
+}
+
+func TestTree_Clone(t *testing.T) {
+	// Arrange
+	tr := newAgeTree()
+	tr.GetChild()[0].DefaultChild = 3
+	tr.GetChild()[0].Headers = map[string]interface{}{"tags": []interface{}{"a"}}
+
+	// Act
+	same := tr.GetChild()[0].Clone()
+	fresh := tr.Clone(func(o *CloneOptions) {
+		o.FirstID = 100
+	})
+
+	// Assert
+	assert.Nil(t, same.GetParent())
+	assert.Equal(t, 2, same.ID)
+	assert.Equal(t, 0, same.ParentID)
+	assert.Equal(t, 3, same.DefaultChild)
+	assert.Equal(t, tr.GetChild()[0].Headers, same.Headers)
+	same.Headers["tags"].([]interface{})[0] = "b"
+	assert.Equal(t, "a", tr.GetChild()[0].Headers["tags"].([]interface{})[0], "the headers are copied")
+
+	ids := []int{}
+	for _, n := range fresh.Flatten() {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []int{100, 101, 102, 103, 104}, ids)
+	assert.Equal(t, 102, fresh.GetChild()[0].DefaultChild)
+	assert.Equal(t, 101, fresh.GetChild()[0].GetChild()[0].ParentID)
+
+	same.GetChild()[0].Name = "changed"
+	assert.Equal(t, "us", tr.GetChild()[0].GetChild()[0].Name)
+}
@@ -0,0 +1,733 @@
+package dtree
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedPMML : The PMML document, or the tree, uses a construct that cannot be converted
+var ErrUnsupportedPMML = errors.New("not supported by the PMML conversion")
+
+const pmmlNamespace = "http://www.dmg.org/PMML-4_4"
+
+type pmmlDocument struct {
+	XMLName        xml.Name           `xml:"PMML"`
+	Xmlns          string             `xml:"xmlns,attr,omitempty"`
+	Version        string             `xml:"version,attr"`
+	Header         pmmlHeader         `xml:"Header"`
+	DataDictionary pmmlDataDictionary `xml:"DataDictionary"`
+	TreeModels     []pmmlTreeModel    `xml:"TreeModel"`
+	Others         []pmmlElement      `xml:",any"`
+}
+
+type pmmlHeader struct {
+	Description string           `xml:"description,attr,omitempty"`
+	Application *pmmlApplication `xml:"Application"`
+}
+
+type pmmlApplication struct {
+	Name string `xml:"name,attr"`
+}
+
+type pmmlDataDictionary struct {
+	NumberOfFields int             `xml:"numberOfFields,attr"`
+	Fields         []pmmlDataField `xml:"DataField"`
+}
+
+type pmmlDataField struct {
+	Name     string `xml:"name,attr"`
+	OpType   string `xml:"optype,attr"`
+	DataType string `xml:"dataType,attr"`
+}
+
+type pmmlTreeModel struct {
+	ModelName            string           `xml:"modelName,attr,omitempty"`
+	FunctionName         string           `xml:"functionName,attr"`
+	NoTrueChildStrategy  string           `xml:"noTrueChildStrategy,attr,omitempty"`
+	MissingValueStrategy string           `xml:"missingValueStrategy,attr,omitempty"`
+	SplitCharacteristic  string           `xml:"splitCharacteristic,attr,omitempty"`
+	MiningSchema         pmmlMiningSchema `xml:"MiningSchema"`
+	Node                 pmmlNode         `xml:"Node"`
+	Others               []pmmlElement    `xml:",any"`
+}
+
+type pmmlMiningSchema struct {
+	Fields []pmmlMiningField `xml:"MiningField"`
+}
+
+type pmmlMiningField struct {
+	Name                    string `xml:"name,attr"`
+	UsageType               string `xml:"usageType,attr,omitempty"`
+	MissingValueReplacement string `xml:"missingValueReplacement,attr,omitempty"`
+}
+
+type pmmlNode struct {
+	ID                 string                  `xml:"id,attr,omitempty"`
+	Score              string                  `xml:"score,attr,omitempty"`
+	RecordCount        string                  `xml:"recordCount,attr,omitempty"`
+	DefaultChild       string                  `xml:"defaultChild,attr,omitempty"`
+	True               *struct{}               `xml:"True"`
+	False              *struct{}               `xml:"False"`
+	SimplePredicate    *pmmlPredicate          `xml:"SimplePredicate"`
+	SimpleSetPredicate *pmmlPredicate          `xml:"SimpleSetPredicate"`
+	CompoundPredicate  *pmmlPredicate          `xml:"CompoundPredicate"`
+	ScoreDistributions []pmmlScoreDistribution `xml:"ScoreDistribution"`
+	Nodes              []*pmmlNode             `xml:"Node"`
+	Others             []pmmlElement           `xml:",any"`
+}
+
+type pmmlPredicate struct {
+	XMLName         xml.Name
+	Field           string          `xml:"field,attr,omitempty"`
+	Operator        string          `xml:"operator,attr,omitempty"`
+	Value           *string         `xml:"value,attr"`
+	BooleanOperator string          `xml:"booleanOperator,attr,omitempty"`
+	Array           *pmmlArray      `xml:"Array"`
+	Predicates      []pmmlPredicate `xml:",any"`
+}
+
+type pmmlArray struct {
+	N      int    `xml:"n,attr,omitempty"`
+	Type   string `xml:"type,attr"`
+	Values string `xml:",chardata"`
+}
+
+type pmmlScoreDistribution struct {
+	Value       string  `xml:"value,attr"`
+	RecordCount float64 `xml:"recordCount,attr"`
+}
+
+// pmmlElement is an element not read by the conversion
+type pmmlElement struct {
+	XMLName xml.Name
+}
+
+// pmmlOperators maps the operators of the SimplePredicate on the dtree ones
+var pmmlOperators = map[string]string{
+	"equal":          "eq",
+	"notEqual":       "ne",
+	"lessThan":       "lt",
+	"lessOrEqual":    "lte",
+	"greaterThan":    "gt",
+	"greaterOrEqual": "gte",
+}
+
+// checkElements returns an error if one of the elements is not in the allowed list
+func checkElements(elements []pmmlElement, allowed ...string) error {
+	for _, e := range elements {
+		ok := false
+		for _, a := range allowed {
+			ok = ok || e.XMLName.Local == a
+		}
+		if !ok {
+			return fmt.Errorf("%v: element %s", ErrUnsupportedPMML, e.XMLName.Local)
+		}
+	}
+	return nil
+}
+
+// pmmlCondition is a condition of a dtree node
+type pmmlCondition struct {
+	key      string
+	operator string
+	value    interface{}
+}
+
+// pmmlAlternative is a way to select a PMML node: all its conditions have to match
+type pmmlAlternative struct {
+	conditions []pmmlCondition
+	node       *pmmlNode
+}
+
+type pmmlImporter struct {
+	// dataTypes are the dataType of the fields, by name
+	dataTypes      map[string]string
+	scoreType      string
+	lastPrediction bool
+	nodes          []Tree
+}
+
+// ImportPMML builds a tree from a PMML document holding one TreeModel.
+// SimplePredicate become nodes with the related operator (equal is eq, lessOrEqual is lte...),
+// True predicates nodes without operator, and the nodes with a False predicate are dropped.
+// A compound "and" predicate becomes a chain of nodes, each one also holding a copy of the
+// following siblings (dtree does not go back to the siblings of a parent), a compound "or"
+// predicate and a SimpleSetPredicate become one node per alternative, each one holding a copy
+// of the children.
+// The score of a node is held by its Content and, for the PMML leaves, by a leaf child (like the
+// trained trees), the ScoreDistribution by the "distribution" Metadata and the recordCount by the
+// "samples" one.
+// Missing values must use the "none" strategy (a predicate on a missing value is false, as in dtree)
+// or the "defaultChild" one (the defaultChild becomes the DefaultChild of the node), the other strategies, transformations, isMissing predicates, "xor" and "surrogate" compound
+// predicates and embedded models return ErrUnsupportedPMML.
+func ImportPMML(document []byte) (*Tree, error) {
+	var doc pmmlDocument
+	if err := xml.Unmarshal(document, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := checkElements(doc.Others, "Extension", "MiningBuildTask"); err != nil {
+		return nil, err
+	}
+	if len(doc.TreeModels) != 1 {
+		return nil, fmt.Errorf("%v: the document must hold one TreeModel, found %d", ErrUnsupportedPMML, len(doc.TreeModels))
+	}
+
+	m := doc.TreeModels[0]
+	if err := checkElements(m.Others, "Extension", "Output", "ModelStats", "ModelExplanation", "ModelVerification"); err != nil {
+		return nil, err
+	}
+	if m.MissingValueStrategy != "" && m.MissingValueStrategy != "none" && m.MissingValueStrategy != "defaultChild" {
+		return nil, fmt.Errorf("%v: missingValueStrategy %s", ErrUnsupportedPMML, m.MissingValueStrategy)
+	}
+
+	im := &pmmlImporter{
+		dataTypes:      make(map[string]string),
+		lastPrediction: m.NoTrueChildStrategy == "returnLastPrediction",
+	}
+	for _, f := range doc.DataDictionary.Fields {
+		im.dataTypes[f.Name] = f.DataType
+	}
+
+	for _, f := range m.MiningSchema.Fields {
+		if f.MissingValueReplacement != "" {
+			return nil, fmt.Errorf("%v: missingValueReplacement of %s", ErrUnsupportedPMML, f.Name)
+		}
+		if f.UsageType == "target" || f.UsageType == "predicted" {
+			im.scoreType = im.dataTypes[f.Name]
+		}
+	}
+	switch m.FunctionName {
+	case "regression":
+		im.scoreType = "double"
+	case "classification":
+	default:
+		return nil, fmt.Errorf("%v: functionName %s", ErrUnsupportedPMML, m.FunctionName)
+	}
+
+	if m.Node.True == nil {
+		return nil, fmt.Errorf("%v: the predicate of the root node must be True", ErrUnsupportedPMML)
+	}
+	if err := im.node(&m.Node, Tree{Name: "root"}, 0); err != nil {
+		return nil, err
+	}
+
+	return CreateTree(im.nodes), nil
+}
+
+func (im *pmmlImporter) add(node Tree, parentID int) int {
+	node.ID = len(im.nodes) + 1
+	node.ParentID = parentID
+	im.nodes = append(im.nodes, node)
+	return node.ID
+}
+
+// node adds the PMML node n as node, then its children
+func (im *pmmlImporter) node(n *pmmlNode, node Tree, parentID int) error {
+	if err := checkElements(n.Others, "Extension", "Partition"); err != nil {
+		return err
+	}
+
+	score, err := im.value(im.scoreType, n.Score)
+	if n.Score == "" {
+		score, err = nil, nil
+	}
+	if err != nil {
+		return fmt.Errorf("score of node %s: %v", n.ID, err)
+	}
+
+	if im.lastPrediction || len(n.Nodes) == 0 {
+		node.Content = score
+	}
+	if node.Metadata, err = pmmlMetadata(n); err != nil {
+		return err
+	}
+	id := im.add(node, parentID)
+
+	if len(n.Nodes) == 0 {
+		if score != nil {
+			im.add(Tree{Name: fmt.Sprint(score), Content: score}, id)
+		}
+		return nil
+	}
+
+	var alternatives []pmmlAlternative
+	for _, child := range n.Nodes {
+		paths, err := im.paths(predicateOf(child))
+		if err != nil {
+			return fmt.Errorf("node %s: %v", child.ID, err)
+		}
+		for _, p := range paths {
+			alternatives = append(alternatives, pmmlAlternative{conditions: p, node: child})
+		}
+	}
+
+	firstIDs := make(map[*pmmlNode]int)
+	for i, a := range alternatives {
+		if _, ok := firstIDs[a.node]; !ok {
+			firstIDs[a.node] = len(im.nodes) + 1
+		}
+		if err := im.chain(a, alternatives[i+1:], id, score, i+1); err != nil {
+			return err
+		}
+	}
+
+	if n.DefaultChild != "" {
+		for _, child := range n.Nodes {
+			if child.ID == n.DefaultChild {
+				im.nodes[id-1].DefaultChild = firstIDs[child]
+			}
+		}
+	}
+	return nil
+}
+
+// alternatives adds the alternatives under the parent, in order
+func (im *pmmlImporter) alternatives(alternatives []pmmlAlternative, parentID int, parentScore interface{}, order int) error {
+	for i, a := range alternatives {
+		if err := im.chain(a, alternatives[i+1:], parentID, parentScore, order+i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chain adds one node per condition of the alternative, each intermediate node holding a copy of the
+// alternatives following it, as the resolution does not go back to the siblings of a parent
+func (im *pmmlImporter) chain(a pmmlAlternative, following []pmmlAlternative, parentID int, parentScore interface{}, order int) error {
+	node := Tree{Order: order}
+	if len(a.conditions) > 0 {
+		node.Key, node.Operator, node.Value = a.conditions[0].key, a.conditions[0].operator, a.conditions[0].value
+	}
+
+	if len(a.conditions) <= 1 {
+		return im.node(a.node, node, parentID)
+	}
+
+	if im.lastPrediction {
+		node.Content = parentScore
+	}
+	id := im.add(node, parentID)
+	next := pmmlAlternative{conditions: a.conditions[1:], node: a.node}
+	if err := im.chain(next, following, id, parentScore, 1); err != nil {
+		return err
+	}
+	return im.alternatives(following, id, parentScore, 2)
+}
+
+// predicateOf returns the predicate of a node, nil if it has none
+func predicateOf(n *pmmlNode) *pmmlPredicate {
+	switch {
+	case n.True != nil:
+		return &pmmlPredicate{XMLName: xml.Name{Local: "True"}}
+	case n.False != nil:
+		return &pmmlPredicate{XMLName: xml.Name{Local: "False"}}
+	case n.SimplePredicate != nil:
+		return n.SimplePredicate
+	case n.SimpleSetPredicate != nil:
+		return n.SimpleSetPredicate
+	case n.CompoundPredicate != nil:
+		return n.CompoundPredicate
+	}
+	return nil
+}
+
+// paths returns the predicate as a list of alternatives, each one being a list of conditions which must all match.
+// True is one alternative without condition, False no alternative.
+func (im *pmmlImporter) paths(p *pmmlPredicate) ([][]pmmlCondition, error) {
+	if p == nil {
+		return nil, fmt.Errorf("%v: node without predicate", ErrUnsupportedPMML)
+	}
+
+	switch p.XMLName.Local {
+	case "True":
+		return [][]pmmlCondition{nil}, nil
+	case "False":
+		return nil, nil
+	case "SimplePredicate":
+		operator, ok := pmmlOperators[p.Operator]
+		if !ok {
+			return nil, fmt.Errorf("%v: operator %s", ErrUnsupportedPMML, p.Operator)
+		}
+		if p.Value == nil {
+			return nil, fmt.Errorf("%v: SimplePredicate on %s without value", ErrBadModel, p.Field)
+		}
+		v, err := im.value(im.dataTypes[p.Field], *p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", p.Field, err)
+		}
+		return [][]pmmlCondition{{{key: p.Field, operator: operator, value: v}}}, nil
+	case "SimpleSetPredicate":
+		if p.Array == nil {
+			return nil, fmt.Errorf("%v: SimpleSetPredicate on %s without array", ErrBadModel, p.Field)
+		}
+		var conditions []pmmlCondition
+		for _, raw := range splitPMMLArray(p.Array.Values) {
+			v, err := im.value(im.dataTypes[p.Field], raw)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", p.Field, err)
+			}
+			conditions = append(conditions, pmmlCondition{key: p.Field, operator: "eq", value: v})
+		}
+
+		switch p.BooleanOperator {
+		case "isIn":
+			var paths [][]pmmlCondition
+			for _, c := range conditions {
+				paths = append(paths, []pmmlCondition{c})
+			}
+			return paths, nil
+		case "isNotIn":
+			for i := range conditions {
+				conditions[i].operator = "ne"
+			}
+			return [][]pmmlCondition{conditions}, nil
+		}
+		return nil, fmt.Errorf("%v: SimpleSetPredicate operator %s", ErrUnsupportedPMML, p.BooleanOperator)
+	case "CompoundPredicate":
+		var children [][][]pmmlCondition
+		for i := range p.Predicates {
+			if p.Predicates[i].XMLName.Local == "Extension" {
+				continue
+			}
+			c, err := im.paths(&p.Predicates[i])
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, c)
+		}
+
+		switch p.BooleanOperator {
+		case "and":
+			paths := [][]pmmlCondition{nil}
+			for _, c := range children {
+				var product [][]pmmlCondition
+				for _, left := range paths {
+					for _, right := range c {
+						path := append(append([]pmmlCondition{}, left...), right...)
+						product = append(product, path)
+					}
+				}
+				paths = product
+			}
+			return paths, nil
+		case "or":
+			var paths [][]pmmlCondition
+			for _, c := range children {
+				paths = append(paths, c...)
+			}
+			return paths, nil
+		}
+		return nil, fmt.Errorf("%v: CompoundPredicate operator %s", ErrUnsupportedPMML, p.BooleanOperator)
+	}
+
+	return nil, fmt.Errorf("%v: predicate %s", ErrUnsupportedPMML, p.XMLName.Local)
+}
+
+// value converts a PMML value according to the data type of its field, a value of an unknown field
+// is a number if it can be parsed, else a string
+func (im *pmmlImporter) value(dataType string, raw string) (interface{}, error) {
+	switch dataType {
+	case "integer", "float", "double":
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %q is not a number", ErrBadModel, raw)
+		}
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %q is not a boolean", ErrBadModel, raw)
+		}
+		return v, nil
+	case "":
+		if v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			return v, nil
+		}
+	}
+
+	return raw, nil
+}
+
+// splitPMMLArray splits the content of an Array on spaces, values can be quoted
+func splitPMMLArray(s string) []string {
+	var values []string
+	var current strings.Builder
+	quoted, inValue := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(s) && s[i+1] == '"':
+			current.WriteByte('"')
+			i++
+		case c == '"':
+			quoted = !quoted
+			inValue = true
+		case !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			if inValue {
+				values = append(values, current.String())
+				current.Reset()
+				inValue = false
+			}
+		default:
+			current.WriteByte(c)
+			inValue = true
+		}
+	}
+	if inValue {
+		values = append(values, current.String())
+	}
+
+	return values
+}
+
+// pmmlMetadata returns the recordCount and the ScoreDistribution of a node
+func pmmlMetadata(n *pmmlNode) (map[string]interface{}, error) {
+	metadata := make(map[string]interface{})
+	if n.RecordCount != "" {
+		samples, err := strconv.ParseFloat(n.RecordCount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%v: recordCount %q of node %s", ErrBadModel, n.RecordCount, n.ID)
+		}
+		metadata["samples"] = samples
+	}
+	if len(n.ScoreDistributions) > 0 {
+		distribution := make(map[string]interface{})
+		for _, d := range n.ScoreDistributions {
+			distribution[d.Value] = d.RecordCount
+		}
+		metadata["distribution"] = distribution
+	}
+
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+// PMMLOptions allow to configure the PMML export
+type PMMLOptions struct {
+	// ModelName is the name of the TreeModel
+	ModelName string
+	// TargetField is the name of the predicted field ("target" by default)
+	TargetField string
+	// FunctionName is "classification" or "regression", by default regression if every score is a number
+	FunctionName string
+}
+
+type pmmlExporter struct {
+	// dataTypes are the dataType of the keys, by name
+	dataTypes    map[string]string
+	scoreTypes   map[string]bool
+	defaultChild bool
+}
+
+// ExportPMML writes the tree as a PMML TreeModel. The nodes without operator and the fallbacks
+// have a True predicate, eq, ne, lt, lte, gt and gte nodes a SimplePredicate, the other operators
+// return ErrUnsupportedPMML. The score of a node is its Content (a string, a number, a bool or a
+// Prediction), or the Name of a leaf without Content. A node having only one leaf child without
+// condition (like the trained trees) gets the score of the leaf. The "samples" and "distribution"
+// Metadata are written as recordCount and ScoreDistribution.
+// The TreeModel uses the returnLastPrediction strategy, which is how Resolve behaves when no child matches,
+// and the defaultChild missing value strategy if a node has a DefaultChild.
+func ExportPMML(t *Tree, options ...func(o *PMMLOptions)) ([]byte, error) {
+	config := &PMMLOptions{TargetField: "target"}
+	for _, option := range options {
+		option(config)
+	}
+
+	if t == nil {
+		return nil, ErrNoNode
+	}
+
+	ex := &pmmlExporter{dataTypes: make(map[string]string), scoreTypes: make(map[string]bool)}
+	root, err := ex.node(t, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ex.dataTypes[config.TargetField]; ok {
+		return nil, fmt.Errorf("the target field %s is a key of the tree", config.TargetField)
+	}
+
+	if config.FunctionName == "" {
+		config.FunctionName = "classification"
+		if len(ex.scoreTypes) == 1 && ex.scoreTypes["double"] {
+			config.FunctionName = "regression"
+		}
+	}
+	if config.FunctionName != "classification" && config.FunctionName != "regression" {
+		return nil, fmt.Errorf("%v: functionName %s", ErrUnsupportedPMML, config.FunctionName)
+	}
+	if config.FunctionName == "regression" && len(ex.scoreTypes) > 0 && !(len(ex.scoreTypes) == 1 && ex.scoreTypes["double"]) {
+		return nil, fmt.Errorf("%v: regression with scores which are not numbers", ErrUnsupportedPMML)
+	}
+
+	targetType := "string"
+	for dataType := range ex.scoreTypes {
+		if len(ex.scoreTypes) == 1 {
+			targetType = dataType
+		}
+	}
+
+	var keys []string
+	for k := range ex.dataTypes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	doc := pmmlDocument{
+		Xmlns:   pmmlNamespace,
+		Version: "4.4",
+		Header:  pmmlHeader{Application: &pmmlApplication{Name: "go-dtree"}},
+	}
+	model := pmmlTreeModel{
+		ModelName:            config.ModelName,
+		FunctionName:         config.FunctionName,
+		NoTrueChildStrategy:  "returnLastPrediction",
+		MissingValueStrategy: "none",
+		SplitCharacteristic:  "multiSplit",
+		Node:                 *root,
+	}
+	if ex.defaultChild {
+		model.MissingValueStrategy = "defaultChild"
+	}
+	for _, k := range keys {
+		doc.DataDictionary.Fields = append(doc.DataDictionary.Fields, pmmlDataField{Name: k, OpType: pmmlOpType(ex.dataTypes[k]), DataType: ex.dataTypes[k]})
+		model.MiningSchema.Fields = append(model.MiningSchema.Fields, pmmlMiningField{Name: k})
+	}
+
+	targetOpType := "categorical"
+	if config.FunctionName == "regression" {
+		targetOpType = "continuous"
+	}
+	doc.DataDictionary.Fields = append(doc.DataDictionary.Fields, pmmlDataField{Name: config.TargetField, OpType: targetOpType, DataType: targetType})
+	doc.DataDictionary.NumberOfFields = len(doc.DataDictionary.Fields)
+	model.MiningSchema.Fields = append(model.MiningSchema.Fields, pmmlMiningField{Name: config.TargetField, UsageType: "target"})
+	doc.TreeModels = []pmmlTreeModel{model}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+func pmmlOpType(dataType string) string {
+	if dataType == "double" {
+		return "continuous"
+	}
+	return "categorical"
+}
+
+// pmmlValue returns a value written for PMML, with its data type
+func pmmlValue(v interface{}) (string, string, bool) {
+	switch value := v.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), "double", true
+	case string:
+		return value, "string", true
+	case bool:
+		return strconv.FormatBool(value), "boolean", true
+	}
+	return "", "", false
+}
+
+func (ex *pmmlExporter) node(n *Tree, root bool) (*pmmlNode, error) {
+	p := &pmmlNode{ID: strconv.Itoa(n.ID)}
+
+	if root || n.Operator == "" || isFallback(n) {
+		p.True = &struct{}{}
+	} else {
+		var operator string
+		for pmmlOperator, o := range pmmlOperators {
+			if o == canonicalOperator(n.Operator) {
+				operator = pmmlOperator
+			}
+		}
+		if operator == "" {
+			return nil, fmt.Errorf("%v: operator %s of node %d", ErrUnsupportedPMML, n.Operator, n.ID)
+		}
+
+		value, dataType, ok := pmmlValue(n.Value)
+		if !ok {
+			return nil, fmt.Errorf("%v: value %v of node %d", ErrUnsupportedPMML, n.Value, n.ID)
+		}
+		if existing, ok := ex.dataTypes[n.Key]; ok && existing != dataType {
+			return nil, fmt.Errorf("%v: key %s has values of different types", ErrUnsupportedPMML, n.Key)
+		}
+		ex.dataTypes[n.Key] = dataType
+
+		p.SimplePredicate = &pmmlPredicate{Field: n.Key, Operator: operator, Value: &value}
+	}
+
+	children := n.GetChild()
+	scored := n
+	if len(children) == 1 && len(children[0].GetChild()) == 0 && (children[0].Operator == "" || isFallback(children[0])) {
+		scored = children[0]
+		children = nil
+	}
+
+	score, err := pmmlScore(scored)
+	if err != nil {
+		return nil, err
+	}
+	if score != nil {
+		value, dataType, _ := pmmlValue(score)
+		p.Score = value
+		ex.scoreTypes[dataType] = true
+	}
+
+	if samples, ok := n.Metadata["samples"].(float64); ok {
+		p.RecordCount = strconv.FormatFloat(samples, 'g', -1, 64)
+	}
+	if distribution, ok := n.Metadata["distribution"].(map[string]interface{}); ok {
+		var values []string
+		for v := range distribution {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		for _, v := range values {
+			if count, ok := distribution[v].(float64); ok {
+				p.ScoreDistributions = append(p.ScoreDistributions, pmmlScoreDistribution{Value: v, RecordCount: count})
+			}
+		}
+	}
+
+	for _, child := range children {
+		c, err := ex.node(child, false)
+		if err != nil {
+			return nil, err
+		}
+		p.Nodes = append(p.Nodes, c)
+
+		if child.ID == n.DefaultChild {
+			p.DefaultChild = c.ID
+			ex.defaultChild = true
+		}
+	}
+
+	return p, nil
+}
+
+// pmmlScore returns the score of a node, nil if it has none
+func pmmlScore(n *Tree) (interface{}, error) {
+	switch c := n.Content.(type) {
+	case string, float64, bool:
+		return c, nil
+	case nil:
+		if len(n.GetChild()) == 0 && n.Name != "" {
+			return n.Name, nil
+		}
+		return nil, nil
+	}
+
+	if v, err := PredictionValue(n); err == nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("%v: content of node %d is not a score", ErrUnsupportedPMML, n.ID)
+}
@@ -0,0 +1,203 @@
+package dtree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportPMML(t *testing.T) {
+	// Arrange
+	document, err := ioutil.ReadFile("testdata/golf.pmml")
+	assert.NoError(t, err)
+
+	// Act
+	tr, err := ImportPMML(document)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", tr.Content)
+	assert.Equal(t, 14.0, tr.Metadata["samples"])
+	assert.Equal(t, map[string]interface{}{"yes": 9.0, "no": 5.0}, tr.Metadata["distribution"])
+
+	tests := []struct {
+		request  map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"outlook": "sunny", "humidity": 80.0, "windy": false}, "no"},
+		{map[string]interface{}{"outlook": "sunny", "humidity": 70.0, "windy": false}, "yes"},
+		{map[string]interface{}{"outlook": "rain", "humidity": 70.0, "windy": true}, "no"},
+		{map[string]interface{}{"outlook": "rain", "humidity": 70.0, "windy": false}, "yes"},
+		{map[string]interface{}{"outlook": "overcast", "humidity": 90.0, "windy": true}, "yes"},
+		{map[string]interface{}{"outlook": "snow", "humidity": 90.0, "windy": true}, "yes"},
+	}
+	for _, test := range tests {
+		node, err := tr.Resolve(test.request)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, Outcome(node), "%v", test.request)
+	}
+	assert.NotContains(t, tr.String(), "maybe", "nodes with a False predicate are dropped")
+}
+
+func TestImportPMML_Or(t *testing.T) {
+	// Arrange
+	document := []byte(`<PMML version="4.4">
+	<DataDictionary>
+		<DataField name="x" optype="categorical" dataType="string"/>
+		<DataField name="y" optype="continuous" dataType="double"/>
+	</DataDictionary>
+	<TreeModel functionName="classification">
+		<MiningSchema><MiningField name="x"/><MiningField name="y"/></MiningSchema>
+		<Node>
+			<True/>
+			<Node>
+				<CompoundPredicate booleanOperator="or">
+					<SimplePredicate field="x" operator="equal" value="a"/>
+					<SimplePredicate field="x" operator="equal" value="b"/>
+				</CompoundPredicate>
+				<Node score="high"><SimplePredicate field="y" operator="greaterThan" value="1"/></Node>
+				<Node score="low"><True/></Node>
+			</Node>
+			<Node score="other"><True/></Node>
+		</Node>
+	</TreeModel>
+</PMML>`)
+
+	// Act
+	tr, err := ImportPMML(document)
+
+	// Assert
+	assert.NoError(t, err)
+	tests := []struct {
+		request  map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"x": "b", "y": 2.0}, "high"},
+		{map[string]interface{}{"x": "a", "y": 0.0}, "low"},
+		{map[string]interface{}{"x": "c", "y": 2.0}, "other"},
+	}
+	for _, test := range tests {
+		node, err := tr.Resolve(test.request)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, Outcome(node), "%v", test.request)
+	}
+	assert.Len(t, tr.GetChild(), 3, "one node per alternative of the or")
+}
+
+func TestImportPMML_Unsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{"xor", `<PMML><TreeModel functionName="classification"><Node><True/><Node score="a"><CompoundPredicate booleanOperator="xor"><True/><False/></CompoundPredicate></Node></Node></TreeModel></PMML>`},
+		{"isMissing", `<PMML><TreeModel functionName="classification"><Node><True/><Node score="a"><SimplePredicate field="x" operator="isMissing"/></Node></Node></TreeModel></PMML>`},
+		{"embedded model", `<PMML><TreeModel functionName="classification"><Node><True/><Regression/></Node></TreeModel></PMML>`},
+		{"missing strategy", `<PMML><TreeModel functionName="classification" missingValueStrategy="aggregateNodes"><Node><True/></Node></TreeModel></PMML>`},
+		{"other model", `<PMML><RegressionModel functionName="regression"/></PMML>`},
+		{"root predicate", `<PMML><TreeModel functionName="classification"><Node><SimplePredicate field="x" operator="equal" value="1"/></Node></TreeModel></PMML>`},
+	}
+	for _, test := range tests {
+		_, err := ImportPMML([]byte(test.document))
+		if assert.Error(t, err, test.name) {
+			assert.True(t, strings.Contains(err.Error(), ErrUnsupportedPMML.Error()), test.name)
+		}
+	}
+}
+
+func TestExportPMML(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+	tr, err := Train(samples)
+	assert.NoError(t, err)
+
+	// Act
+	document, err := ExportPMML(tr, func(o *PMMLOptions) {
+		o.TargetField = "play"
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, string(document), `<TreeModel functionName="classification" noTrueChildStrategy="returnLastPrediction"`)
+	assert.Contains(t, string(document), `<DataField name="outlook" optype="categorical" dataType="string">`)
+	assert.Contains(t, string(document), `<MiningField name="play" usageType="target">`)
+
+	imported, err := ImportPMML(document)
+	assert.NoError(t, err)
+	for _, s := range samples {
+		expected, _ := tr.Resolve(s.Request)
+		actual, err := imported.Resolve(s.Request)
+		assert.NoError(t, err)
+		assert.Equal(t, Outcome(expected), Outcome(actual))
+	}
+}
+
+func TestExportPMML_Regression(t *testing.T) {
+	// Arrange
+	tr, err := TrainRegression(deliverySamples(), func(o *TrainOptions) { o.MaxDepth = 2 })
+	assert.NoError(t, err)
+
+	// Act
+	document, err := ExportPMML(tr)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, string(document), `functionName="regression"`)
+
+	imported, err := ImportPMML(document)
+	assert.NoError(t, err)
+	for _, s := range deliverySamples() {
+		expected, _ := tr.Predict(s.Request)
+		actual, err := imported.Predict(s.Request)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestExportPMML_Unsupported(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Key: "email", Operator: "regexp", Value: ".*@example.com"},
+	})
+
+	// Act
+	_, err := ExportPMML(tr)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUnsupportedPMML.Error())
+}
+
+func TestPMML_DefaultChild(t *testing.T) {
+	// Arrange
+	document := []byte(`<PMML version="4.4">
+	<DataDictionary>
+		<DataField name="age" optype="continuous" dataType="double"/>
+	</DataDictionary>
+	<TreeModel functionName="classification" missingValueStrategy="defaultChild">
+		<MiningSchema><MiningField name="age"/></MiningSchema>
+		<Node id="root" defaultChild="adult">
+			<True/>
+			<Node id="child" score="child"><SimplePredicate field="age" operator="lessThan" value="18"/></Node>
+			<Node id="adult" score="adult"><SimplePredicate field="age" operator="greaterOrEqual" value="18"/></Node>
+		</Node>
+	</TreeModel>
+</PMML>`)
+
+	// Act
+	tr, err := ImportPMML(document)
+
+	// Assert
+	assert.NoError(t, err)
+	node, err := tr.Resolve(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, "adult", Outcome(node))
+
+	exported, err := ExportPMML(tr)
+	assert.NoError(t, err)
+	assert.Contains(t, string(exported), `missingValueStrategy="defaultChild"`)
+	assert.Contains(t, string(exported), fmt.Sprintf(`defaultChild="%d"`, tr.DefaultChild))
+}
@@ -0,0 +1,357 @@
+package dtree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Calibration methods
+const (
+	// PlattCalibration fits a sigmoid on the probability of each class (Platt scaling)
+	PlattCalibration = "platt"
+	// IsotonicCalibration fits a non-decreasing step function on the probability of each class
+	IsotonicCalibration = "isotonic"
+)
+
+// ProbaOptions allow to configure the probabilities computed from the nodes
+type ProbaOptions struct {
+	// Laplace is added to the count of every class (1 for the Laplace smoothing, 0 by default)
+	Laplace float64
+	// Classes are the classes (written with fmt.Sprint) which can be predicted, by default
+	// the ones of the distribution of the root
+	Classes []string
+	// Calibration is applied on the probabilities
+	Calibration *Calibration
+	// TreeOptions are given to Resolve
+	TreeOptions []func(t *TreeOptions)
+}
+
+// ClassCalibration maps the probability of a class to a calibrated one
+type ClassCalibration struct {
+	// A and B are the parameters of the sigmoid 1 / (1 + exp(-(A * p + B))) of the Platt scaling
+	A float64 `json:"a,omitempty"`
+	B float64 `json:"b,omitempty"`
+	// X and Y are the points of the isotonic function, interpolated linearly between them
+	X []float64 `json:"x,omitempty"`
+	Y []float64 `json:"y,omitempty"`
+}
+
+// Calibration maps the probabilities of a tree to calibrated ones, one class against the others
+type Calibration struct {
+	Method  string                       `json:"method"`
+	Classes map[string]*ClassCalibration `json:"classes"`
+}
+
+// distributionOf returns the distribution of the samples of a node: its "distribution" Metadata, or
+// the one of its closest ancestor (a pruned leaf has none), or its outcome if no node has one
+func distributionOf(node *Tree) map[string]float64 {
+	for n := node; n != nil; n = n.GetParent() {
+		d, ok := n.Metadata["distribution"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		distribution := make(map[string]float64, len(d))
+		for c, v := range d {
+			if f, ok := v.(float64); ok {
+				distribution[c] = f
+			}
+		}
+		return distribution
+	}
+
+	return map[string]float64{classKey(Outcome(node)): 1}
+}
+
+// NodeProbabilities returns the probability of each class (written with fmt.Sprint) at a node, from
+// the distribution of its samples (see distributionOf), smoothed and calibrated according to the options
+func NodeProbabilities(node *Tree, options ...func(o *ProbaOptions)) (map[string]float64, error) {
+	config := &ProbaOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	if node == nil {
+		return nil, ErrNoNode
+	}
+
+	classes := config.Classes
+	if len(classes) == 0 {
+		root := node
+		for root.GetParent() != nil {
+			root = root.GetParent()
+		}
+		if _, ok := root.Metadata["distribution"]; ok {
+			for c := range distributionOf(root) {
+				classes = append(classes, c)
+			}
+		}
+	}
+
+	counts := distributionOf(node)
+	for _, c := range classes {
+		if _, ok := counts[c]; !ok {
+			counts[c] = 0
+		}
+	}
+
+	var total float64
+	for c := range counts {
+		counts[c] += config.Laplace
+		total += counts[c]
+	}
+
+	probabilities := make(map[string]float64, len(counts))
+	for c, n := range counts {
+		if total > 0 {
+			probabilities[c] = n / total
+		}
+	}
+
+	if config.Calibration != nil {
+		probabilities = config.Calibration.Apply(probabilities)
+	}
+
+	return probabilities, nil
+}
+
+// ResolveProba resolves the request and returns the probability of each class (written with fmt.Sprint)
+// at the selected node, see NodeProbabilities
+func (t *Tree) ResolveProba(request map[string]interface{}, options ...func(o *ProbaOptions)) (map[string]float64, error) {
+	config := &ProbaOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	node, err := t.Resolve(request, config.TreeOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NodeProbabilities(node, options...)
+}
+
+// Calibrate fits a calibration of the probabilities of the tree (computed with the options) on a
+// validation set, which should not be the training one
+func Calibrate(t *Tree, validation []Sample, method string, options ...func(o *ProbaOptions)) (*Calibration, error) {
+	if len(validation) == 0 {
+		return nil, ErrNoSample
+	}
+	if method != PlattCalibration && method != IsotonicCalibration {
+		return nil, fmt.Errorf("unknown calibration %q", method)
+	}
+
+	// the options of the caller are copied, append could write in their spare capacity
+	noCalibration := append(append([]func(o *ProbaOptions){}, options...), func(o *ProbaOptions) {
+		o.Calibration = nil
+	})
+
+	scores := make([]map[string]float64, len(validation))
+	classes := make(map[string]bool)
+	for i, s := range validation {
+		p, err := t.ResolveProba(s.Request, noCalibration...)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+		scores[i] = p
+		for c := range p {
+			classes[c] = true
+		}
+		classes[classKey(s.Label)] = true
+	}
+
+	c := &Calibration{Method: method, Classes: make(map[string]*ClassCalibration)}
+	for class := range classes {
+		x := make([]float64, len(validation))
+		y := make([]float64, len(validation))
+		for i, s := range validation {
+			x[i] = scores[i][class]
+			if classKey(s.Label) == class {
+				y[i] = 1
+			}
+		}
+
+		if method == PlattCalibration {
+			c.Classes[class] = fitPlatt(x, y)
+		} else {
+			c.Classes[class] = fitIsotonic(x, y)
+		}
+	}
+
+	return c, nil
+}
+
+// Apply calibrates the probability of each class, then normalizes them so that they sum to 1
+func (c *Calibration) Apply(probabilities map[string]float64) map[string]float64 {
+	calibrated := make(map[string]float64, len(probabilities))
+	var total float64
+	for class, p := range probabilities {
+		cc, ok := c.Classes[class]
+		if !ok {
+			calibrated[class] = p
+		} else if c.Method == IsotonicCalibration {
+			calibrated[class] = cc.isotonic(p)
+		} else {
+			calibrated[class] = sigmoid(cc.A*p + cc.B)
+		}
+		total += calibrated[class]
+	}
+
+	if total > 0 && len(calibrated) > 1 {
+		for class := range calibrated {
+			calibrated[class] /= total
+		}
+	}
+
+	return calibrated
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// fitPlatt fits 1 / (1 + exp(-(A * x + B))) on the labels with the Newton method, on the targets
+// regularized as proposed by Platt
+func fitPlatt(x, y []float64) *ClassCalibration {
+	var positives, negatives float64
+	for _, v := range y {
+		positives += v
+	}
+	negatives = float64(len(y)) - positives
+
+	targets := make([]float64, len(y))
+	for i, v := range y {
+		if v == 1 {
+			targets[i] = (positives + 1) / (positives + 2)
+		} else {
+			targets[i] = 1 / (negatives + 2)
+		}
+	}
+
+	loss := func(a, b float64) float64 {
+		var l float64
+		for i := range x {
+			z := a*x[i] + b
+			// log(1 + exp(-z)) and log(1 + exp(z)), computed without overflow
+			l += targets[i]*softplus(-z) + (1-targets[i])*softplus(z)
+		}
+		return l
+	}
+
+	a, b := 0.0, math.Log((positives+1)/(negatives+1))
+	current := loss(a, b)
+	for iteration := 0; iteration < 100; iteration++ {
+		var gA, gB, hAA, hAB, hBB float64
+		for i := range x {
+			p := sigmoid(a*x[i] + b)
+			d := p - targets[i]
+			w := math.Max(p*(1-p), 1e-12)
+			gA += d * x[i]
+			gB += d
+			hAA += w * x[i] * x[i]
+			hAB += w * x[i]
+			hBB += w
+		}
+
+		hAA += 1e-9
+		hBB += 1e-9
+		det := hAA*hBB - hAB*hAB
+		if det <= 0 {
+			break
+		}
+		stepA := (hBB*gA - hAB*gB) / det
+		stepB := (hAA*gB - hAB*gA) / det
+
+		improved := false
+		for rate := 1.0; rate >= 1e-8; rate /= 2 {
+			na, nb := a-rate*stepA, b-rate*stepB
+			if l := loss(na, nb); l < current {
+				a, b, current, improved = na, nb, l, true
+				break
+			}
+		}
+		if !improved || math.Abs(stepA)+math.Abs(stepB) < 1e-10 {
+			break
+		}
+	}
+
+	return &ClassCalibration{A: a, B: b}
+}
+
+func softplus(z float64) float64 {
+	if z > 30 {
+		return z
+	}
+	return math.Log1p(math.Exp(z))
+}
+
+// fitIsotonic fits a non-decreasing function on the labels (pool adjacent violators)
+func fitIsotonic(x, y []float64) *ClassCalibration {
+	order := make([]int, len(x))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return x[order[i]] < x[order[j]]
+	})
+
+	type block struct {
+		min, max, sum, weight float64
+	}
+	var blocks []block
+	for k, i := range order {
+		if k > 0 && x[order[k-1]] == x[i] {
+			// the equal probabilities are pooled before any violation
+			blocks[len(blocks)-1].sum += y[i]
+			blocks[len(blocks)-1].weight++
+		} else {
+			blocks = append(blocks, block{min: x[i], max: x[i], sum: y[i], weight: 1})
+		}
+		if k+1 < len(order) && x[order[k+1]] == x[i] {
+			continue
+		}
+
+		for len(blocks) > 1 {
+			last, previous := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if previous.sum/previous.weight < last.sum/last.weight {
+				break
+			}
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{min: previous.min, max: last.max, sum: previous.sum + last.sum, weight: previous.weight + last.weight})
+		}
+	}
+
+	c := &ClassCalibration{}
+	for _, b := range blocks {
+		v := b.sum / b.weight
+		c.X = append(c.X, b.min)
+		c.Y = append(c.Y, v)
+		if b.max > b.min {
+			c.X = append(c.X, b.max)
+			c.Y = append(c.Y, v)
+		}
+	}
+
+	return c
+}
+
+// isotonic interpolates the isotonic function, the values outside of the fitted range are clipped
+func (c *ClassCalibration) isotonic(p float64) float64 {
+	if len(c.X) == 0 {
+		return p
+	}
+	if p <= c.X[0] {
+		return c.Y[0]
+	}
+	if p >= c.X[len(c.X)-1] {
+		return c.Y[len(c.Y)-1]
+	}
+
+	i := sort.SearchFloat64s(c.X, p)
+	if c.X[i] == p {
+		return c.Y[i]
+	}
+	ratio := (p - c.X[i-1]) / (c.X[i] - c.X[i-1])
+	return c.Y[i-1] + ratio*(c.Y[i]-c.Y[i-1])
+}
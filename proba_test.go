@@ -0,0 +1,96 @@
+package dtree
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveProba(t *testing.T) {
+	// Arrange
+	model, err := ioutil.ReadFile("testdata/sklearn_iris.json")
+	assert.NoError(t, err)
+	tr, err := ImportSklearn(model)
+	assert.NoError(t, err)
+	request := map[string]interface{}{"petal_length": 4.0, "petal_width": 1.3}
+
+	// Act
+	p, err := tr.ResolveProba(request)
+	smoothed, errSmoothed := tr.ResolveProba(request, func(o *ProbaOptions) {
+		o.Laplace = 1
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"setosa": 0, "versicolor": 49.0 / 54, "virginica": 5.0 / 54}, p)
+	assert.NoError(t, errSmoothed)
+	assert.Equal(t, map[string]float64{"setosa": 1.0 / 57, "versicolor": 50.0 / 57, "virginica": 6.0 / 57}, smoothed)
+}
+
+func TestResolveProba_HandWrittenTree(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Key: "age", Operator: "gte", Value: 18.0, Content: "adult"},
+	})
+
+	// Act
+	p, err := tr.ResolveProba(map[string]interface{}{"age": 20.0}, func(o *ProbaOptions) {
+		o.Classes = []string{"adult", "child"}
+		o.Laplace = 1
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"adult": 2.0 / 3, "child": 1.0 / 3}, p)
+}
+
+func TestCalibrate(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root", Metadata: map[string]interface{}{"distribution": map[string]interface{}{"yes": 10.0, "no": 10.0}}},
+		{ID: 2, ParentID: 1, Key: "x", Operator: "eq", Value: "a", Content: "yes", Metadata: map[string]interface{}{"distribution": map[string]interface{}{"yes": 9.0, "no": 1.0}}},
+		{ID: 3, ParentID: 1, Key: "x", Operator: "eq", Value: "b", Content: "no", Metadata: map[string]interface{}{"distribution": map[string]interface{}{"yes": 1.0, "no": 9.0}}},
+	})
+	// the tree is overconfident: only 60% of the "a" are "yes"
+	var validation []Sample
+	for i := 0; i < 10; i++ {
+		a, b := "no", "yes"
+		if i < 6 {
+			a, b = "yes", "no"
+		}
+		validation = append(validation,
+			Sample{Request: map[string]interface{}{"x": "a"}, Label: a},
+			Sample{Request: map[string]interface{}{"x": "b"}, Label: b})
+	}
+
+	for _, method := range []string{PlattCalibration, IsotonicCalibration} {
+		// Act
+		c, err := Calibrate(tr, validation, method)
+		assert.NoError(t, err)
+		p, errProba := tr.ResolveProba(map[string]interface{}{"x": "a"}, func(o *ProbaOptions) {
+			o.Calibration = c
+		})
+
+		// Assert
+		assert.NoError(t, errProba)
+		assert.InDelta(t, 0.6, p["yes"], 0.02, method)
+		assert.InDelta(t, 1, p["yes"]+p["no"], 1e-9, method)
+	}
+
+	_, err := Calibrate(tr, validation, "beta")
+	assert.Error(t, err)
+	_, err = Calibrate(tr, nil, PlattCalibration)
+	assert.Equal(t, ErrNoSample, err)
+
+	// the options of the caller are not overwritten
+	laplace := func(o *ProbaOptions) {
+		o.Laplace = 1
+	}
+	options := make([]func(o *ProbaOptions), 1, 2)
+	options[0] = laplace
+	_, err = Calibrate(tr, validation, PlattCalibration, options...)
+	assert.NoError(t, err)
+	assert.Nil(t, options[:2][1])
+}
@@ -0,0 +1,299 @@
+package dtree
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// PrunedNode is a node whose children were replaced by a leaf
+type PrunedNode struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	// Outcome is the majority outcome held by the new leaf
+	Outcome interface{} `json:"outcome"`
+	// RemovedIDs are the ids of the removed nodes
+	RemovedIDs []int `json:"removed_ids"`
+	// Samples is the number of samples going through the node
+	Samples int `json:"samples"`
+}
+
+// PruneReport lists what was removed by a pruning
+type PruneReport struct {
+	Pruned []PrunedNode `json:"pruned"`
+	// ErrorsBefore and ErrorsAfter are the number of misclassified samples
+	ErrorsBefore int `json:"errors_before"`
+	ErrorsAfter  int `json:"errors_after"`
+	LeavesBefore int `json:"leaves_before"`
+	LeavesAfter  int `json:"leaves_after"`
+}
+
+// Outcome returns the outcome of a node: its Content, or its Name if it has no Content
+func Outcome(node *Tree) interface{} {
+	if node == nil {
+		return nil
+	}
+	if node.Content != nil {
+		return node.Content
+	}
+	return node.Name
+}
+
+// sameOutcome compares an outcome with a label
+func sameOutcome(outcome, label interface{}) bool {
+	switch outcome.(type) {
+	case string, float64, bool:
+		return outcome == label
+	}
+	return reflect.DeepEqual(outcome, label)
+}
+
+// pruneStats are the samples going through a node
+type pruneStats struct {
+	counts map[interface{}]int
+	total  int
+	// endErrors is the number of misclassified samples whose resolution ends on the node
+	endErrors int
+}
+
+type pruner struct {
+	tree    *Tree
+	samples []Sample
+	options []func(t *TreeOptions)
+	stats   map[*Tree]*pruneStats
+	nextID  int
+	report  *PruneReport
+}
+
+func newPruner(t *Tree, samples []Sample, options []func(t *TreeOptions)) (*pruner, error) {
+	if t == nil {
+		return nil, ErrNoNode
+	}
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+	for _, s := range samples {
+		switch s.Label.(type) {
+		case string, float64, bool:
+		default:
+			return nil, ErrBadLabel
+		}
+	}
+
+	p := &pruner{tree: t, samples: samples, options: options, report: &PruneReport{}}
+	p.count()
+	p.report.ErrorsBefore = p.subtreeErrors(t)
+	p.report.LeavesBefore = countLeaves(t)
+
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		if n.ID >= p.nextID {
+			p.nextID = n.ID + 1
+		}
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	visit(t)
+
+	return p, nil
+}
+
+// count resolves every sample and counts its label on every node of its path
+func (p *pruner) count() {
+	p.stats = make(map[*Tree]*pruneStats)
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		p.stats[n] = &pruneStats{counts: make(map[interface{}]int)}
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	visit(p.tree)
+
+	for _, s := range p.samples {
+		node, _ := p.tree.Resolve(s.Request, p.options...)
+		if node == nil {
+			continue
+		}
+
+		if !sameOutcome(Outcome(node), s.Label) {
+			p.stats[node].endErrors++
+		}
+		for n := node; n != nil; n = n.GetParent() {
+			if st, ok := p.stats[n]; ok {
+				st.counts[s.Label]++
+				st.total++
+			}
+			if n == p.tree {
+				break
+			}
+		}
+	}
+}
+
+// majority returns the most frequent label of the node and the number of samples with another label
+func (p *pruner) majority(n *Tree) (interface{}, int) {
+	st := p.stats[n]
+	var best interface{}
+	bestCount := -1
+	for label, c := range st.counts {
+		if c > bestCount || (c == bestCount && fmt.Sprint(label) < fmt.Sprint(best)) {
+			best, bestCount = label, c
+		}
+	}
+	return best, st.total - bestCount
+}
+
+// subtreeErrors returns the number of misclassified samples whose resolution ends under n
+func (p *pruner) subtreeErrors(n *Tree) int {
+	errors := p.stats[n].endErrors
+	for _, child := range n.GetChild() {
+		errors += p.subtreeErrors(child)
+	}
+	return errors
+}
+
+// prune replaces the children of n by a leaf holding the majority outcome
+func (p *pruner) prune(n *Tree) {
+	outcome, _ := p.majority(n)
+
+	pruned := PrunedNode{
+		ID:          n.ID,
+		Description: n.ValueToDraw(),
+		Outcome:     outcome,
+		Samples:     p.stats[n].total,
+	}
+	var visit func(c *Tree)
+	visit = func(c *Tree) {
+		pruned.RemovedIDs = append(pruned.RemovedIDs, c.ID)
+		for _, child := range c.GetChild() {
+			visit(child)
+		}
+	}
+	for _, child := range n.GetChild() {
+		visit(child)
+	}
+	p.report.Pruned = append(p.report.Pruned, pruned)
+
+	for _, child := range n.nodes {
+		child.parent = nil
+		unindexTree(child)
+	}
+	n.nodes = nil
+	// the child followed when a key is missing is removed too
+	n.DefaultChild = 0
+
+	leaf := &Tree{ID: p.nextID, ParentID: n.ID, Name: fmt.Sprint(outcome), Content: outcome}
+	p.nextID++
+	n.AddNode(leaf)
+}
+
+func (p *pruner) finish() *PruneReport {
+	p.count()
+	p.report.ErrorsAfter = p.subtreeErrors(p.tree)
+	p.report.LeavesAfter = countLeaves(p.tree)
+	return p.report
+}
+
+func countLeaves(n *Tree) int {
+	if len(n.GetChild()) == 0 {
+		return 1
+	}
+
+	leaves := 0
+	for _, child := range n.GetChild() {
+		leaves += countLeaves(child)
+	}
+	return leaves
+}
+
+// PruneReducedError prunes the tree (in place) against a validation set: going from the bottom,
+// the children of a node are replaced by a leaf holding the majority outcome of the samples
+// reaching the node, if it does not increase the number of misclassified samples.
+// The outcome of a node is its Content (or its Name), nodes not reached by any sample are kept.
+// It works on trained and on hand-written classification trees.
+func PruneReducedError(t *Tree, validation []Sample, options ...func(t *TreeOptions)) (*PruneReport, error) {
+	p, err := newPruner(t, validation, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var visit func(n *Tree) int
+	visit = func(n *Tree) int {
+		if len(n.GetChild()) == 0 {
+			return p.stats[n].endErrors
+		}
+
+		errors := p.stats[n].endErrors
+		for _, child := range n.GetChild() {
+			errors += visit(child)
+		}
+
+		if _, leafErrors := p.majority(n); p.stats[n].total > 0 && leafErrors <= errors && !isPrunedLeaf(n) {
+			p.prune(n)
+			return leafErrors
+		}
+		return errors
+	}
+	visit(t)
+
+	return p.finish(), nil
+}
+
+// PruneCostComplexity prunes the tree (in place) with the weakest link pruning of CART: while
+// a node has a cost complexity lower or equal to alpha, the children of the node with the lowest one
+// are replaced by a leaf holding the majority outcome. The cost complexity of a node is the increase
+// of the misclassification rate on the samples divided by the number of leaves removed.
+// With alpha at 0 only the subtrees that do not improve the samples classification are pruned,
+// a bigger alpha prunes more. Nodes not reached by any sample are kept.
+func PruneCostComplexity(t *Tree, samples []Sample, alpha float64, options ...func(t *TreeOptions)) (*PruneReport, error) {
+	p, err := newPruner(t, samples, options)
+	if err != nil {
+		return nil, err
+	}
+
+	n := float64(len(samples))
+	for {
+		var weakest *Tree
+		min := math.Inf(1)
+
+		var visit func(node *Tree)
+		visit = func(node *Tree) {
+			if len(node.GetChild()) == 0 || isPrunedLeaf(node) {
+				return
+			}
+
+			if p.stats[node].total > 0 {
+				_, leafErrors := p.majority(node)
+				leaves := countLeaves(node)
+				if leaves > 1 {
+					g := float64(leafErrors-p.subtreeErrors(node)) / n / float64(leaves-1)
+					if g < min {
+						weakest, min = node, g
+					}
+				}
+			}
+
+			for _, child := range node.GetChild() {
+				visit(child)
+			}
+		}
+		visit(t)
+
+		if weakest == nil || min > alpha {
+			break
+		}
+
+		p.prune(weakest)
+		p.count()
+	}
+
+	return p.finish(), nil
+}
+
+// isPrunedLeaf returns true if the node has only one child, a leaf without condition
+func isPrunedLeaf(n *Tree) bool {
+	children := n.GetChild()
+	return len(children) == 1 && len(children[0].GetChild()) == 0 && children[0].Operator == "" && !isFallback(children[0])
+}
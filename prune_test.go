@@ -0,0 +1,134 @@
+package dtree
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneCostComplexity(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+	tr, err := Train(samples)
+	assert.NoError(t, err)
+	leaves := countLeaves(tr)
+
+	// Act
+	report, err := PruneCostComplexity(tr, samples, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, report.Pruned, "a tree learning every sample should not be pruned with alpha at 0")
+	assert.Equal(t, leaves, report.LeavesAfter)
+
+	// Act
+	report, err = PruneCostComplexity(tr, samples, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, leaves, report.LeavesBefore)
+	assert.Equal(t, 1, report.LeavesAfter, "a big alpha should prune everything")
+	assert.Equal(t, 0, report.ErrorsBefore)
+	assert.Equal(t, 5, report.ErrorsAfter)
+	if assert.Len(t, tr.GetChild(), 1) {
+		assert.Equal(t, "yes", tr.GetChild()[0].Content)
+	}
+	last := report.Pruned[len(report.Pruned)-1]
+	assert.Equal(t, 1, last.ID)
+	assert.Equal(t, 14, last.Samples)
+}
+
+func TestPruneReducedError(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root"},
+		{"id": 2, "parent_id": 1, "key": "age", "operator": "gt", "value": 18, "order": 1},
+		{"id": 3, "parent_id": 2, "key": "country", "operator": "eq", "value": "FR", "order": 1},
+		{"id": 4, "parent_id": 3, "name": "accept"},
+		{"id": 5, "parent_id": 2, "value": "fallback"},
+		{"id": 6, "parent_id": 5, "name": "reject"},
+		{"id": 7, "parent_id": 1, "value": "fallback"},
+		{"id": 8, "parent_id": 7, "name": "reject"},
+		{"id": 9, "parent_id": 1, "key": "vip", "operator": "eq", "value": true, "order": 2},
+		{"id": 10, "parent_id": 9, "name": "accept"}
+	]`))
+	assert.NoError(t, err)
+
+	validation := []Sample{
+		{Request: map[string]interface{}{"age": 30.0, "country": "FR"}, Label: "accept"},
+		{Request: map[string]interface{}{"age": 30.0, "country": "US"}, Label: "accept"},
+		{Request: map[string]interface{}{"age": 40.0, "country": "DE"}, Label: "accept"},
+		{Request: map[string]interface{}{"age": 12.0}, Label: "reject"},
+	}
+
+	// Act
+	report, err := PruneReducedError(tr, validation)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.ErrorsBefore)
+	assert.Equal(t, 0, report.ErrorsAfter)
+	if assert.Len(t, report.Pruned, 1) {
+		assert.Equal(t, 2, report.Pruned[0].ID)
+		assert.Equal(t, "accept", report.Pruned[0].Outcome)
+		assert.ElementsMatch(t, []int{3, 4, 5, 6}, report.Pruned[0].RemovedIDs)
+		assert.Equal(t, 3, report.Pruned[0].Samples)
+	}
+	assert.Equal(t, 3, report.LeavesAfter)
+
+	node, _ := tr.Resolve(map[string]interface{}{"age": 50.0, "country": "US"})
+	assert.Equal(t, "accept", node.Content)
+	assert.Equal(t, 11, node.ID, "the new leaf gets a new id")
+	assert.Equal(t, 2, node.GetParent().ID)
+
+	node, _ = tr.Resolve(map[string]interface{}{"vip": true})
+	assert.Equal(t, 10, node.ID, "nodes without sample are kept")
+}
+
+func TestPruneCostComplexity_DefaultChild(t *testing.T) {
+	// Arrange
+	dump, err := ioutil.ReadFile("testdata/xgboost_dump.json")
+	assert.NoError(t, err)
+	e, err := ImportXGBoost(dump)
+	assert.NoError(t, err)
+	tr := e.Trees[0]
+	assert.NotZero(t, tr.DefaultChild)
+
+	samples := []Sample{
+		{Request: map[string]interface{}{"f0": 1.0}, Label: -0.4},
+		{Request: map[string]interface{}{"f0": 5.0, "f1": 1.0}, Label: -0.4},
+		{Request: map[string]interface{}{"f0": 5.0, "f1": 2.0}, Label: -0.4},
+	}
+
+	// Act
+	report, err := PruneCostComplexity(tr, samples, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.LeavesAfter)
+	assert.Zero(t, tr.DefaultChild, "the default child was pruned")
+	assert.NoError(t, Validate(tr.Flatten()))
+	node, err := tr.Resolve(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, -0.4, node.Content)
+}
+
+func TestPrune_Errors(t *testing.T) {
+	tr, _ := LoadTree(treeTest)
+
+	_, err := PruneReducedError(tr, nil)
+	assert.Equal(t, ErrNoSample, err)
+
+	_, err = PruneCostComplexity(nil, []Sample{{Label: "a"}}, 0)
+	assert.Equal(t, ErrNoNode, err)
+
+	_, err = PruneCostComplexity(tr, []Sample{{Label: map[string]interface{}{}}}, 0)
+	assert.Equal(t, ErrBadLabel, err)
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, "a", Outcome(&Tree{Name: "a"}))
+	assert.Equal(t, 1.0, Outcome(&Tree{Name: "a", Content: 1.0}))
+	assert.Nil(t, Outcome(nil))
+}
@@ -0,0 +1,201 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoPrediction : The selected node does not hold a numeric prediction
+var ErrNoPrediction = errors.New("node has no prediction")
+
+// Prediction is the Content of the nodes of a regression tree
+type Prediction struct {
+	Mean    float64 `json:"mean"`
+	Samples float64 `json:"samples"`
+	StdDev  float64 `json:"std_dev"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// TrainRegression learns a regression tree from the samples (CART), the labels must be numbers.
+// Numeric keys are split with a lte and a gt node on the threshold that reduces the most the variance,
+// keys with string or bool values with one eq node per value.
+// Every node holds a Prediction (mean and statistics of its samples) on its Content.
+func TrainRegression(samples []Sample, options ...func(o *TrainOptions)) (*Tree, error) {
+	config := &TrainOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	for _, s := range samples {
+		if _, ok := s.Label.(float64); !ok {
+			return nil, ErrBadLabel
+		}
+	}
+
+	return induce(samples, config, regression{})
+}
+
+// regression learns the mean of the labels
+type regression struct{}
+
+type varianceStats struct {
+	sum, sumSquares, total float64
+}
+
+func (regression) newStats() targetStats {
+	return &varianceStats{}
+}
+
+func (s *varianceStats) add(r trainRow, sign float64) {
+	v := numericLabel(r.label)
+	s.sum += sign * r.weight * v
+	s.sumSquares += sign * r.weight * v * v
+	s.total += sign * r.weight
+}
+
+// numericLabel returns the number learned from a label
+func numericLabel(label interface{}) float64 {
+	switch l := label.(type) {
+	case float64:
+		return l
+	case gradient:
+		return l.residual
+	}
+	return 0
+}
+
+func (s *varianceStats) weight() float64 {
+	return s.total
+}
+
+// impurity is the variance of the labels
+func (s *varianceStats) impurity() float64 {
+	if s.total <= 0 {
+		return 0
+	}
+
+	mean := s.sum / s.total
+	return math.Max(0, s.sumSquares/s.total-mean*mean)
+}
+
+func (regression) content(rows []trainRow) interface{} {
+	return newPrediction(rows)
+}
+
+func (regression) name(content interface{}) string {
+	return fmt.Sprintf("%.4g", content.(Prediction).Mean)
+}
+
+func newPrediction(rows []trainRow) Prediction {
+	s := &varianceStats{}
+	p := Prediction{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, r := range rows {
+		s.add(r, 1)
+		v := r.label.(float64)
+		p.Min = math.Min(p.Min, v)
+		p.Max = math.Max(p.Max, v)
+	}
+
+	if s.total > 0 {
+		p.Mean = s.sum / s.total
+	}
+	if len(rows) == 0 {
+		p.Min, p.Max = 0, 0
+	}
+	p.Samples = s.total
+	p.StdDev = math.Sqrt(s.impurity())
+
+	return p
+}
+
+// PredictionValue returns the number predicted by a node: its Content can be a number,
+// a Prediction or the map decoded from the json of a Prediction
+func PredictionValue(node *Tree) (float64, error) {
+	if node == nil {
+		return 0, ErrNoNode
+	}
+
+	switch c := node.Content.(type) {
+	case float64:
+		return c, nil
+	case Prediction:
+		return c.Mean, nil
+	case *Prediction:
+		return c.Mean, nil
+	case map[string]interface{}:
+		if v, ok := c["mean"].(float64); ok {
+			return v, nil
+		}
+	}
+
+	return 0, ErrNoPrediction
+}
+
+// Predict resolves the request and returns the number predicted by the selected node
+func (t *Tree) Predict(request map[string]interface{}, options ...func(t *TreeOptions)) (float64, error) {
+	node, err := t.Resolve(request, options...)
+	if err != nil {
+		return 0, err
+	}
+
+	return PredictionValue(node)
+}
+
+// RegressionMetrics measures the predictions of a tree on labeled samples
+type RegressionMetrics struct {
+	// MAE is the mean absolute error
+	MAE float64 `json:"mae"`
+	// RMSE is the root mean squared error
+	RMSE float64 `json:"rmse"`
+	// R2 is the coefficient of determination (1 is a perfect prediction)
+	R2    float64 `json:"r2"`
+	Count int     `json:"count"`
+}
+
+// EvaluateRegression predicts every sample (usually a held-out set) and compares it to its label
+func EvaluateRegression(t *Tree, samples []Sample, options ...func(t *TreeOptions)) (RegressionMetrics, error) {
+	var m RegressionMetrics
+	if len(samples) == 0 {
+		return m, ErrNoSample
+	}
+
+	var sum float64
+	labels := make([]float64, len(samples))
+	for i, s := range samples {
+		v, ok := s.Label.(float64)
+		if !ok {
+			return m, ErrBadLabel
+		}
+		labels[i] = v
+		sum += v
+	}
+	mean := sum / float64(len(samples))
+
+	var absolute, squares, total float64
+	for i, s := range samples {
+		p, err := t.Predict(s.Request, options...)
+		if err != nil {
+			return m, fmt.Errorf("sample %d: %v", i, err)
+		}
+
+		diff := labels[i] - p
+		absolute += math.Abs(diff)
+		squares += diff * diff
+		total += (labels[i] - mean) * (labels[i] - mean)
+	}
+
+	n := float64(len(samples))
+	m.Count = len(samples)
+	m.MAE = absolute / n
+	m.RMSE = math.Sqrt(squares / n)
+	switch {
+	case total > 0:
+		m.R2 = 1 - squares/total
+	case squares == 0:
+		m.R2 = 1
+	}
+
+	return m, nil
+}
@@ -0,0 +1,111 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deliverySamples : the delivery time depends on the distance and on the city
+func deliverySamples() []Sample {
+	var samples []Sample
+	for i := 0; i < 40; i++ {
+		distance := float64(i % 20)
+		city := "paris"
+		if i%2 == 1 {
+			city = "lyon"
+		}
+		minutes := 20.0
+		if distance > 9 {
+			minutes = 45
+		}
+		if city == "lyon" {
+			minutes += 10
+		}
+		samples = append(samples, Sample{
+			Request: map[string]interface{}{"distance": distance, "city": city},
+			Label:   minutes,
+		})
+	}
+	return samples
+}
+
+func TestTrainRegression(t *testing.T) {
+	// Arrange
+	samples := deliverySamples()
+
+	// Act
+	tr, err := TrainRegression(samples)
+
+	// Assert
+	assert.NoError(t, err)
+	if assert.Len(t, tr.GetChild(), 2) {
+		assert.Equal(t, "distance", tr.GetChild()[0].Key, "the distance reduces the most the variance")
+		assert.Equal(t, "lte", tr.GetChild()[0].Operator)
+		assert.Equal(t, 9.5, tr.GetChild()[0].Value)
+	}
+
+	root := tr.Content.(Prediction)
+	assert.Equal(t, 40.0, root.Samples)
+	assert.Equal(t, 37.5, root.Mean)
+	assert.Equal(t, 20.0, root.Min)
+	assert.Equal(t, 55.0, root.Max)
+
+	v, err := tr.Predict(map[string]interface{}{"distance": 15.0, "city": "lyon"})
+	assert.NoError(t, err)
+	assert.Equal(t, 55.0, v)
+
+	node, _ := tr.Resolve(map[string]interface{}{"distance": 15.0, "city": "lyon"})
+	assert.Equal(t, "55", node.Name)
+	assert.Equal(t, 0.0, node.Content.(Prediction).StdDev)
+}
+
+func TestTrainRegression_Bad_Label(t *testing.T) {
+	_, err := TrainRegression([]Sample{{Request: map[string]interface{}{"a": 1.0}, Label: "x"}})
+
+	assert.Equal(t, ErrBadLabel, err)
+}
+
+func TestEvaluateRegression(t *testing.T) {
+	// Arrange
+	samples := deliverySamples()
+	stump, err := TrainRegression(samples, func(o *TrainOptions) {
+		o.MaxDepth = 1
+	})
+	assert.NoError(t, err)
+	full, err := TrainRegression(samples)
+	assert.NoError(t, err)
+
+	// Act
+	stumpMetrics, err := EvaluateRegression(stump, samples)
+	assert.NoError(t, err)
+	fullMetrics, err := EvaluateRegression(full, samples)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 40, stumpMetrics.Count)
+	assert.Equal(t, 5.0, stumpMetrics.MAE)
+	assert.Equal(t, 5.0, stumpMetrics.RMSE)
+	assert.InDelta(t, 1-25/(12.5*12.5+25), stumpMetrics.R2, 1e-9)
+	assert.Equal(t, RegressionMetrics{Count: 40, R2: 1}, fullMetrics)
+}
+
+func TestPredictionValue_From_Json(t *testing.T) {
+	// Arrange
+	tr, err := TrainRegression(deliverySamples())
+	assert.NoError(t, err)
+	b, err := MarshalTree(tr)
+	assert.NoError(t, err)
+
+	// Act
+	loaded, err := LoadTree(b)
+	assert.NoError(t, err)
+	v, err := loaded.Predict(map[string]interface{}{"distance": 1.0, "city": "paris"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, v)
+
+	_, err = PredictionValue(&Tree{Content: "a"})
+	assert.Equal(t, ErrNoPrediction, err)
+}
@@ -0,0 +1,153 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReplayOptions allow to configure Replay
+type ReplayOptions struct {
+	// Examples is the maximum number of requests kept as examples of each transition (3 by default)
+	Examples int
+	// Match is the matching of the nodes of the two trees, see DiffOptions (MatchAuto by default)
+	Match string
+	// TreeOptions are given to the resolutions
+	TreeOptions []func(t *TreeOptions)
+}
+
+// ReplayExample is a request which decision changed
+type ReplayExample struct {
+	// Index is the position of the request in the replayed ones
+	Index   int                    `json:"index"`
+	Request map[string]interface{} `json:"request"`
+	// OldPath and NewPath are the ids of the nodes from the root (excluded) to the selected node
+	OldPath  []int  `json:"old_path"`
+	NewPath  []int  `json:"new_path"`
+	OldError string `json:"old_error,omitempty"`
+	NewError string `json:"new_error,omitempty"`
+}
+
+// Transition groups the requests going from a node of the old tree to a node of the new tree
+type Transition struct {
+	From     int             `json:"from"`
+	To       int             `json:"to"`
+	FromNode string          `json:"from_node"`
+	ToNode   string          `json:"to_node"`
+	Count    int             `json:"count"`
+	Examples []ReplayExample `json:"examples"`
+}
+
+// ReplayReport lists the decisions which change between two versions of a tree
+type ReplayReport struct {
+	Requests int `json:"requests"`
+	Changed  int `json:"changed"`
+	// Transitions are sorted by count, the most frequent first
+	Transitions []Transition `json:"transitions"`
+}
+
+// Replay resolves the requests against the old and the new trees, and groups the requests whose
+// decision changed by the nodes selected in both trees. A decision changes when the selected node is
+// not the same (the nodes are matched as by Diff), when its outcome (Content, or Name) changed, or
+// when only one of the resolutions failed.
+func Replay(before, after *Tree, requests []map[string]interface{}, options ...func(o *ReplayOptions)) *ReplayReport {
+	config := &ReplayOptions{Examples: 3, Match: MatchAuto}
+	for _, option := range options {
+		option(config)
+	}
+
+	matches := Diff(before, after, func(o *DiffOptions) {
+		o.Match = config.Match
+	}).matches
+
+	type transitionKey struct {
+		from, to *Tree
+	}
+	transitions := make(map[transitionKey]*Transition)
+
+	r := &ReplayReport{Requests: len(requests), Transitions: []Transition{}}
+	for i, request := range requests {
+		b, errBefore := before.Resolve(request, config.TreeOptions...)
+		a, errAfter := after.Resolve(request, config.TreeOptions...)
+
+		if matches[b] == a && classKey(Outcome(b)) == classKey(Outcome(a)) && (errBefore == nil) == (errAfter == nil) {
+			continue
+		}
+		r.Changed++
+
+		key := transitionKey{b, a}
+		t, ok := transitions[key]
+		if !ok {
+			t = &Transition{Examples: []ReplayExample{}}
+			if b != nil {
+				t.From, t.FromNode = b.ID, b.ValueToDraw()
+			}
+			if a != nil {
+				t.To, t.ToNode = a.ID, a.ValueToDraw()
+			}
+			transitions[key] = t
+		}
+		t.Count++
+
+		if len(t.Examples) < config.Examples {
+			e := ReplayExample{Index: i, Request: request, OldPath: pathIDs(b), NewPath: pathIDs(a)}
+			if errBefore != nil {
+				e.OldError = errBefore.Error()
+			}
+			if errAfter != nil {
+				e.NewError = errAfter.Error()
+			}
+			t.Examples = append(t.Examples, e)
+		}
+	}
+
+	for _, t := range transitions {
+		r.Transitions = append(r.Transitions, *t)
+	}
+	sort.Slice(r.Transitions, func(i, j int) bool {
+		ti, tj := r.Transitions[i], r.Transitions[j]
+		if ti.Count != tj.Count {
+			return ti.Count > tj.Count
+		}
+		if ti.From != tj.From {
+			return ti.From < tj.From
+		}
+		return ti.To < tj.To
+	})
+
+	return r
+}
+
+// String writes the number of changed decisions, then each transition with its examples
+func (r *ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests: %d, changed: %d (%s)\n", r.Requests, r.Changed, percentOf(r.Changed, r.Requests))
+
+	for _, t := range r.Transitions {
+		fmt.Fprintf(&b, "%d: %d (%s) -> %d (%s)\n", t.Count, t.From, t.FromNode, t.To, t.ToNode)
+		for _, e := range t.Examples {
+			request, _ := json.Marshal(e.Request)
+			fmt.Fprintf(&b, "	request %d %s\n", e.Index, request)
+			fmt.Fprintf(&b, "		old path %s%s\n", joinIDs(e.OldPath), errorSuffix(e.OldError))
+			fmt.Fprintf(&b, "		new path %s%s\n", joinIDs(e.NewPath), errorSuffix(e.NewError))
+		}
+	}
+
+	return b.String()
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func errorSuffix(err string) string {
+	if err == "" {
+		return ""
+	}
+	return " (error: " + err + ")"
+}
@@ -0,0 +1,72 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	// Arrange
+	before := loadHello(t)
+	after := before.Clone()
+	after.FindByID(10).Value = 65.0
+	after.FindByID(12).Value = 65.0
+	after.FindByID(4).Content = "See you"
+	requests := []map[string]interface{}{
+		{"sayHello": true, "gender": "M", "age": 62.0},
+		{"sayHello": true, "gender": "M", "age": 63.0},
+		{"sayHello": true, "gender": "M", "age": 70.0},
+		{"sayHello": true, "gender": "F"},
+		{"sayHello": false},
+	}
+
+	// Act
+	r := Replay(before, after, requests, func(o *ReplayOptions) {
+		o.Examples = 1
+	})
+
+	// Assert
+	assert.Equal(t, 5, r.Requests)
+	assert.Equal(t, 3, r.Changed)
+	if assert.Len(t, r.Transitions, 2) {
+		tr := r.Transitions[0]
+		assert.Equal(t, 11, tr.From)
+		assert.Equal(t, 13, tr.To)
+		assert.Equal(t, "Hello Sir", tr.FromNode)
+		assert.Equal(t, "Hello dude", tr.ToNode)
+		assert.Equal(t, 2, tr.Count)
+		assert.Equal(t, []ReplayExample{{Index: 0, Request: requests[0], OldPath: []int{2, 9, 10, 11}, NewPath: []int{2, 9, 12, 13}}}, tr.Examples)
+
+		assert.Equal(t, 4, r.Transitions[1].From, "the content of the leaf changed")
+		assert.Equal(t, 4, r.Transitions[1].To)
+	}
+	assert.Contains(t, r.String(), "requests: 5, changed: 3 (60.0%)")
+	assert.Contains(t, r.String(), "2: 11 (Hello Sir) -> 13 (Hello dude)")
+	assert.Contains(t, r.String(), "old path [2 9 10 11]")
+}
+
+func TestReplay_New_IDs(t *testing.T) {
+	// Arrange
+	before := loadHello(t)
+	after := before.Clone(func(o *CloneOptions) {
+		o.FirstID = 100
+	})
+	requests := []map[string]interface{}{
+		{"sayHello": false},
+		{"sayHello": "yes"},
+	}
+
+	// Act
+	same := Replay(before, after, requests)
+	failing := Replay(before, after, requests, func(o *ReplayOptions) {
+		o.Match = MatchByID
+	})
+
+	// Assert
+	assert.Equal(t, 0, same.Changed, "the nodes are matched by path")
+	assert.Empty(t, same.Transitions)
+	assert.Equal(t, 1, failing.Changed, "only the roots are matched by id")
+	assert.Equal(t, 4, failing.Transitions[0].From)
+	assert.Equal(t, "Goodbye", failing.Transitions[0].ToNode)
+}
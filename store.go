@@ -0,0 +1,281 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrInvalidTree : the nodes do not build a valid tree
+var ErrInvalidTree = errors.New("invalid tree")
+
+// ErrNodeNotFound : no node has the requested id
+var ErrNodeNotFound = errors.New("node not found")
+
+// ErrVersionConflict : the tree has been published since the expected version
+var ErrVersionConflict = errors.New("version conflict")
+
+// Validate checks that the nodes build a valid tree: positive and unique ids, a single root, parents
+// which exist without cycle, known operators (the ones of the options included) and default children
+// which are children of their node
+func Validate(nodes []Tree, options ...func(t *TreeOptions)) error {
+	config := newTreeOptions(&TreeOptions{}, options...)
+
+	if len(nodes) == 0 {
+		return fmt.Errorf("%w: no node", ErrInvalidTree)
+	}
+
+	byID := make(map[int]*Tree, len(nodes))
+	root := 0
+	for i := range nodes {
+		n := &nodes[i]
+		if n.ID <= 0 {
+			return fmt.Errorf("%w: node %d has an invalid id", ErrInvalidTree, n.ID)
+		}
+		if _, ok := byID[n.ID]; ok {
+			return fmt.Errorf("%w: several nodes have the id %d", ErrInvalidTree, n.ID)
+		}
+		byID[n.ID] = n
+
+		if n.ParentID == 0 {
+			if root != 0 {
+				return fmt.Errorf("%w: nodes %d and %d are both roots", ErrInvalidTree, root, n.ID)
+			}
+			root = n.ID
+		}
+
+		if _, ok := config.Operators[n.Operator]; n.Operator != "" && !ok && !isExistingOperator(n.Operator) {
+			return fmt.Errorf("%w: node %d: %v %q", ErrInvalidTree, n.ID, ErrOperator, n.Operator)
+		}
+	}
+	if root == 0 {
+		return fmt.Errorf("%w: no root", ErrInvalidTree)
+	}
+
+	for _, n := range byID {
+		// every node must reach the root, a cycle never does
+		current := n
+		for steps := 0; current.ParentID != 0; steps++ {
+			parent, ok := byID[current.ParentID]
+			if !ok {
+				return fmt.Errorf("%w: node %d has an unknown parent %d", ErrInvalidTree, current.ID, current.ParentID)
+			}
+			if steps > len(nodes) {
+				return fmt.Errorf("%w: node %d is on a cycle", ErrInvalidTree, n.ID)
+			}
+			current = parent
+		}
+
+		if n.DefaultChild != 0 {
+			if child, ok := byID[n.DefaultChild]; !ok || child.ParentID != n.ID {
+				return fmt.Errorf("%w: the default child %d of node %d is not one of its children", ErrInvalidTree, n.DefaultChild, n.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Version is a published version of a tree. Its Tree must not be modified, the resolutions running
+// on it share it.
+type Version struct {
+	Number int
+	Tree   *Tree
+}
+
+// Store publishes the versions of a tree: every change is validated then published atomically as a
+// new Version, the resolutions running on the previous one keep using it. It is safe for concurrent use.
+type Store struct {
+	// mu serializes the changes, the readers only load current
+	mu      sync.Mutex
+	current atomic.Value
+	options []func(t *TreeOptions)
+}
+
+// NewStore creates a Store which version 1 is the tree. The options (custom operators) are used
+// to validate the changes.
+func NewStore(t *Tree, options ...func(t *TreeOptions)) *Store {
+	s := &Store{options: options}
+	s.current.Store(&Version{Number: 1, Tree: t})
+
+	return s
+}
+
+// Current returns the last published version
+func (s *Store) Current() *Version {
+	return s.current.Load().(*Version)
+}
+
+// Publish validates the nodes and publishes them as a new version. If expected is not 0, it must be
+// the number of the current version (ErrVersionConflict otherwise).
+func (s *Store) Publish(expected int, nodes []Tree) (*Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.publish(expected, nodes)
+}
+
+func (s *Store) publish(expected int, nodes []Tree) (*Version, error) {
+	current := s.Current()
+	if expected != 0 && expected != current.Number {
+		return nil, fmt.Errorf("%w: expected version %d, current version is %d", ErrVersionConflict, expected, current.Number)
+	}
+
+	if err := Validate(nodes, s.options...); err != nil {
+		return nil, err
+	}
+
+	// CreateTree links the nodes it is given, the caller keeps its own copy
+	data := make([]Tree, len(nodes))
+	copy(data, nodes)
+
+	v := &Version{Number: current.Number + 1, Tree: CreateTree(data)}
+	s.current.Store(v)
+
+	return v, nil
+}
+
+// Edit applies the changes of edit on a copy of the nodes of the current version, then publishes
+// them (see Publish). Nothing is published if edit returns an error.
+func (s *Store) Edit(expected int, edit func(e *Editor) error) (*Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &Editor{nodes: s.Current().Tree.Flatten()}
+	if err := edit(e); err != nil {
+		return nil, err
+	}
+
+	return s.publish(expected, e.nodes)
+}
+
+// Editor changes the flat nodes of a tree, see Store.Edit
+type Editor struct {
+	nodes []Tree
+}
+
+// Nodes returns the nodes being edited
+func (e *Editor) Nodes() []Tree {
+	return e.nodes
+}
+
+func (e *Editor) index(id int) int {
+	for i := range e.nodes {
+		if e.nodes[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Node returns the node with the id
+func (e *Editor) Node(id int) (Tree, error) {
+	i := e.index(id)
+	if i < 0 {
+		return Tree{}, fmt.Errorf("%w: %d", ErrNodeNotFound, id)
+	}
+
+	return e.nodes[i], nil
+}
+
+// Add adds a node under its ParentID and returns its id. A node without ID gets the next free one.
+func (e *Editor) Add(node Tree) (int, error) {
+	if e.index(node.ParentID) < 0 {
+		return 0, fmt.Errorf("%w: parent %d", ErrNodeNotFound, node.ParentID)
+	}
+
+	if node.ID == 0 {
+		for _, n := range e.nodes {
+			if n.ID > node.ID {
+				node.ID = n.ID
+			}
+		}
+		node.ID++
+	} else if e.index(node.ID) >= 0 {
+		return 0, fmt.Errorf("%w: several nodes have the id %d", ErrInvalidTree, node.ID)
+	}
+
+	e.nodes = append(e.nodes, node)
+	return node.ID, nil
+}
+
+// Update replaces the node having the same ID, except its ParentID (see Move)
+func (e *Editor) Update(node Tree) error {
+	i := e.index(node.ID)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrNodeNotFound, node.ID)
+	}
+
+	node.ParentID = e.nodes[i].ParentID
+	e.nodes[i] = node
+	return nil
+}
+
+// Move moves the node (with its descendants) under a new parent, with a new order
+func (e *Editor) Move(id int, parentID int, order int) error {
+	i := e.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrNodeNotFound, id)
+	}
+	if e.nodes[i].ParentID == 0 {
+		return fmt.Errorf("%w: the root cannot be moved", ErrInvalidTree)
+	}
+
+	for p := parentID; p != 0; {
+		if p == id {
+			return fmt.Errorf("%w: node %d cannot be moved under its descendant %d", ErrInvalidTree, id, parentID)
+		}
+		j := e.index(p)
+		if j < 0 {
+			return fmt.Errorf("%w: parent %d", ErrNodeNotFound, p)
+		}
+		p = e.nodes[j].ParentID
+	}
+
+	previous := e.nodes[i].ParentID
+	e.nodes[i].ParentID = parentID
+	e.nodes[i].Order = order
+
+	// the previous parent cannot follow it by default anymore
+	if j := e.index(previous); j >= 0 && e.nodes[j].DefaultChild == id {
+		e.nodes[j].DefaultChild = 0
+	}
+	return nil
+}
+
+// Delete removes the node and its descendants
+func (e *Editor) Delete(id int) error {
+	i := e.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrNodeNotFound, id)
+	}
+	if e.nodes[i].ParentID == 0 {
+		return fmt.Errorf("%w: the root cannot be deleted", ErrInvalidTree)
+	}
+
+	removed := map[int]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, n := range e.nodes {
+			if removed[n.ParentID] && !removed[n.ID] {
+				removed[n.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	nodes := e.nodes[:0]
+	for _, n := range e.nodes {
+		if removed[n.ID] {
+			continue
+		}
+		if removed[n.DefaultChild] {
+			n.DefaultChild = 0
+		}
+		nodes = append(nodes, n)
+	}
+	e.nodes = nodes
+
+	return nil
+}
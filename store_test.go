@@ -0,0 +1,100 @@
+package dtree
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []Tree
+	}{
+		{"no node", nil},
+		{"invalid id", []Tree{{ID: 0}}},
+		{"duplicate id", []Tree{{ID: 1}, {ID: 2, ParentID: 1}, {ID: 2, ParentID: 1}}},
+		{"several roots", []Tree{{ID: 1}, {ID: 2}}},
+		{"unknown parent", []Tree{{ID: 1}, {ID: 2, ParentID: 3}}},
+		{"cycle", []Tree{{ID: 1}, {ID: 2, ParentID: 3}, {ID: 3, ParentID: 2}}},
+		{"unknown operator", []Tree{{ID: 1}, {ID: 2, ParentID: 1, Operator: "between"}}},
+		{"default child", []Tree{{ID: 1, DefaultChild: 3}, {ID: 2, ParentID: 1}, {ID: 3, ParentID: 2}}},
+	}
+	for _, test := range tests {
+		err := Validate(test.nodes)
+
+		assert.Error(t, err, test.name)
+		assert.True(t, errors.Is(err, ErrInvalidTree), test.name)
+	}
+
+	assert.NoError(t, Validate([]Tree{{ID: 1}, {ID: 2, ParentID: 1, Operator: "between"}}, func(t *TreeOptions) {
+		t.Operators = map[string]Operator{"between": nil}
+	}))
+}
+
+func TestStore_Edit(t *testing.T) {
+	// Arrange
+	s := NewStore(CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Key: "age", Operator: "gte", Value: 18.0, Name: "adult"},
+		{ID: 3, ParentID: 1, Value: FallbackType, Name: "child"},
+	}))
+	old := s.Current()
+
+	// Act
+	v, err := s.Edit(1, func(e *Editor) error {
+		id, err := e.Add(Tree{ParentID: 1, Key: "age", Operator: "gte", Value: 65.0, Name: "senior"})
+		assert.Equal(t, 4, id)
+		if err != nil {
+			return err
+		}
+		return e.Move(id, 1, -1)
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v.Number)
+	assert.Equal(t, v, s.Current())
+
+	node, _ := v.Tree.Resolve(map[string]interface{}{"age": 70.0})
+	assert.Equal(t, "senior", node.Name)
+	node, _ = old.Tree.Resolve(map[string]interface{}{"age": 70.0})
+	assert.Equal(t, "adult", node.Name, "the previous version is untouched")
+
+	_, err = s.Edit(1, func(e *Editor) error { return nil })
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	_, err = s.Edit(0, func(e *Editor) error { return e.Delete(5) })
+	assert.True(t, errors.Is(err, ErrNodeNotFound))
+	assert.Equal(t, 2, s.Current().Number)
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	// Arrange
+	s := NewStore(CreateTree([]Tree{{ID: 1, Name: "root"}}))
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := s.Edit(0, func(e *Editor) error {
+				_, err := e.Add(Tree{ParentID: 1})
+				return err
+			})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := s.Current().Tree.Resolve(map[string]interface{}{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, 21, s.Current().Number)
+	assert.Len(t, s.Current().Tree.GetChild(), 20)
+}
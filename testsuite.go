@@ -0,0 +1,313 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// TestSuite is a list of cases checked against a tree, usually stored as json next to the tree file
+//
+//	{
+//		"tree": "tree.json",
+//		"cases": [
+//			{"name": "old man", "request": {"age": 70}, "expected_name": "Hello Sir", "expected_path": [2, 9, 10, 11]}
+//		]
+//	}
+type TestSuite struct {
+	// Tree is the path of the tree file, relative to the suite file
+	Tree  string     `json:"tree,omitempty"`
+	Cases []TestCase `json:"cases"`
+}
+
+// TestCase is a request and the node it must be resolved to
+type TestCase struct {
+	Name    string                 `json:"name"`
+	Request map[string]interface{} `json:"request"`
+	// ExpectedID and/or ExpectedName identify the expected node
+	ExpectedID   int    `json:"expected_id,omitempty"`
+	ExpectedName string `json:"expected_name,omitempty"`
+	// ExpectedPath is the optional list of the selected node ids, from the child of the root to the expected node
+	ExpectedPath []int `json:"expected_path,omitempty"`
+}
+
+// TestResult is the result of a TestCase
+type TestResult struct {
+	Case TestCase
+	Node *Tree
+	Path []int
+	Err  error
+	// Failures explains why the case failed, empty if it passed
+	Failures []string
+	// Diff compares the expected and the actual decision paths when the case failed
+	Diff string
+}
+
+// Passed returns true if the case resolved to the expected node
+func (r TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// TestReport is the result of a TestSuite
+type TestReport struct {
+	Results []TestResult
+	Passed  int
+	Failed  int
+}
+
+// String prints the failed cases and a summary
+func (r *TestReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		if result.Passed() {
+			continue
+		}
+		fmt.Fprintf(&b, "--- FAIL: %s\n", result.Case.Name)
+		for _, f := range result.Failures {
+			fmt.Fprintf(&b, "    %s\n", f)
+		}
+		if result.Diff != "" {
+			fmt.Fprintf(&b, "    path:\n%s", result.Diff)
+		}
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed\n", r.Passed, r.Failed)
+	return b.String()
+}
+
+// LoadTestSuite gets a json and builds the TestSuite related
+func LoadTestSuite(jsonSuite []byte) (*TestSuite, error) {
+	var s TestSuite
+	if err := json.Unmarshal(jsonSuite, &s); err != nil {
+		return nil, err
+	}
+
+	for i, c := range s.Cases {
+		if c.ExpectedID == 0 && c.ExpectedName == "" {
+			return nil, fmt.Errorf("case %d (%s): expected_id or expected_name is required", i, c.Name)
+		}
+		if c.Name == "" {
+			s.Cases[i].Name = fmt.Sprintf("case %d", i)
+		}
+	}
+
+	return &s, nil
+}
+
+// Run resolves every case against the tree
+func (s *TestSuite) Run(t *Tree, options ...func(t *TreeOptions)) *TestReport {
+	report := &TestReport{}
+	for _, c := range s.Cases {
+		result := runTestCase(t, c, options...)
+		if result.Passed() {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+func runTestCase(t *Tree, c TestCase, options ...func(t *TreeOptions)) TestResult {
+	request := c.Request
+	if request == nil {
+		request = make(map[string]interface{})
+	}
+
+	result := TestResult{Case: c}
+	result.Node, result.Err = t.Resolve(request, options...)
+	if result.Err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("resolve error: %v", result.Err))
+	}
+	if result.Node == nil {
+		result.Failures = append(result.Failures, "no node selected")
+		return result
+	}
+	result.Path = pathIDs(result.Node)
+
+	if c.ExpectedID != 0 && result.Node.ID != c.ExpectedID {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected node %d, got %d (%s)", c.ExpectedID, result.Node.ID, result.Node.ValueToDraw()))
+	}
+	if c.ExpectedName != "" && result.Node.Name != c.ExpectedName {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected name %q, got %q (node %d)", c.ExpectedName, result.Node.Name, result.Node.ID))
+	}
+
+	expectedPath := c.ExpectedPath
+	if len(expectedPath) > 0 {
+		if !equalIDs(expectedPath, result.Path) {
+			result.Failures = append(result.Failures, fmt.Sprintf("expected path %v, got %v", expectedPath, result.Path))
+		}
+	} else if expected := findExpected(t, c); expected != nil {
+		expectedPath = pathIDs(expected)
+	}
+
+	if len(result.Failures) > 0 && expectedPath != nil {
+		result.Diff = diffPaths(t, expectedPath, result.Path)
+	}
+
+	return result
+}
+
+// findExpected searches the node expected by the case
+func findExpected(t *Tree, c TestCase) *Tree {
+	var found *Tree
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		if found != nil {
+			return
+		}
+		if (c.ExpectedID == 0 || n.ID == c.ExpectedID) && (c.ExpectedName == "" || n.Name == c.ExpectedName) {
+			found = n
+			return
+		}
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	visit(t)
+	return found
+}
+
+// pathIDs returns the ids of the nodes from the child of the root to n
+func pathIDs(n *Tree) []int {
+	var ids []int
+	for ; n != nil && n.GetParent() != nil; n = n.GetParent() {
+		ids = append([]int{n.ID}, ids...)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffPaths writes the common part of the paths, then the expected nodes (-) and the actual ones (+)
+func diffPaths(t *Tree, expected, actual []int) string {
+	nodes := make(map[int]*Tree)
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		nodes[n.ID] = n
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	visit(t)
+
+	describe := func(id int) string {
+		if n, ok := nodes[id]; ok {
+			return fmt.Sprintf("%d %s", id, n.ValueToDraw())
+		}
+		return fmt.Sprintf("%d (unknown node)", id)
+	}
+
+	var b strings.Builder
+	i := 0
+	for ; i < len(expected) && i < len(actual) && expected[i] == actual[i]; i++ {
+		fmt.Fprintf(&b, "      %s\n", describe(expected[i]))
+	}
+	for _, id := range expected[i:] {
+		fmt.Fprintf(&b, "    - %s\n", describe(id))
+	}
+	for _, id := range actual[i:] {
+		fmt.Fprintf(&b, "    + %s\n", describe(id))
+	}
+
+	return b.String()
+}
+
+// LoadTestFile loads the suite stored on path and its tree, which is validated (see Validate, the
+// options giving the custom operators)
+func LoadTestFile(path string, options ...func(t *TreeOptions)) (*TestSuite, *Tree, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := LoadTestSuite(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.Tree == "" {
+		return nil, nil, fmt.Errorf("%s: the tree file is not defined", path)
+	}
+
+	treePath := s.Tree
+	if !filepath.IsAbs(treePath) {
+		treePath = filepath.Join(filepath.Dir(path), treePath)
+	}
+
+	jsonTree, err := ioutil.ReadFile(treePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nodes []Tree
+	if err := json.Unmarshal(jsonTree, &nodes); err != nil {
+		return nil, nil, err
+	}
+	if err := Validate(nodes, options...); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", treePath, err)
+	}
+
+	t := CreateTree(nodes)
+	if t == nil {
+		return nil, nil, fmt.Errorf("%s: %w: no root", treePath, ErrInvalidTree)
+	}
+	return s, t, nil
+}
+
+// RunTestFile loads the suite stored on path and its tree, and runs it
+func RunTestFile(path string, options ...func(t *TreeOptions)) (*TestReport, error) {
+	s, t, err := LoadTestFile(path, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Run(t, options...), nil
+}
+
+// TestingT is the part of *testing.T used by AssertTestFile
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertTestFile runs the suite stored on path and reports each failed case on t
+//
+//	func TestMyTree(t *testing.T) {
+//		dtree.AssertTestFile(t, "testdata/mytree.test.json")
+//	}
+func AssertTestFile(t TestingT, path string, options ...func(t *TreeOptions)) bool {
+	t.Helper()
+
+	report, err := RunTestFile(path, options...)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return false
+	}
+
+	for _, result := range report.Results {
+		if result.Passed() {
+			continue
+		}
+		msg := fmt.Sprintf("%s: %s\n%s", path, result.Case.Name, strings.Join(result.Failures, "\n"))
+		if result.Diff != "" {
+			msg += "\npath:\n" + result.Diff
+		}
+		t.Errorf("%s", msg)
+	}
+
+	return report.Failed == 0
+}
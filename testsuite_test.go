@@ -0,0 +1,96 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertTestFile(t *testing.T) {
+	AssertTestFile(t, "testdata/hello.test.json")
+}
+
+func TestTestSuite_Failures(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree(treeTest)
+	assert.NoError(t, err)
+
+	s, err := LoadTestSuite([]byte(`{"cases": [
+		{"name": "ok", "request": {"isTest": true, "count": 15}, "expected_name": "FinalNode 2"},
+		{"name": "bad leaf", "request": {"isTest": true, "count": 5}, "expected_id": 6},
+		{"name": "bad path", "request": {"isTest": false}, "expected_id": 3, "expected_path": [4, 3]}
+	]}`))
+	assert.NoError(t, err)
+
+	// Act
+	report := s.Run(tr)
+
+	// Assert
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 2, report.Failed)
+	assert.True(t, report.Results[0].Passed())
+	assert.Equal(t, []int{4, 7, 8}, report.Results[1].Path)
+	assert.Equal(t, []string{"expected node 6, got 8 (FinalNode 1)"}, report.Results[1].Failures)
+	assert.Equal(t, "      4 isTest eq true\n    - 5 count gt 10\n    - 6 FinalNode 2\n    + 7 count lt 10\n    + 8 FinalNode 1\n", report.Results[1].Diff)
+	assert.Equal(t, []string{"expected path [4 3], got [2 3]"}, report.Results[2].Failures)
+	assert.Contains(t, report.String(), "--- FAIL: bad leaf")
+	assert.Contains(t, report.String(), "1 passed, 2 failed")
+}
+
+func TestAssertTestFile_Errors(t *testing.T) {
+	// Arrange
+	f := &fakeT{}
+
+	// Act
+	ok := AssertTestFile(f, "testdata/missing.test.json")
+
+	// Assert
+	assert.False(t, ok)
+	assert.Len(t, f.errors, 1)
+}
+
+func TestLoadTestSuite_Without_Expectation(t *testing.T) {
+	_, err := LoadTestSuite([]byte(`{"cases": [{"request": {}}]}`))
+
+	assert.Error(t, err, "a case without expected node should be rejected")
+}
+
+func TestLoadTestFile_Invalid_Tree(t *testing.T) {
+	// Arrange
+	dir, err := ioutil.TempDir("", "dtree")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	trees := map[string]string{
+		"empty":          `[]`,
+		"unknown_parent": `[{"id": 1}, {"id": 2, "parent_id": 3}]`,
+	}
+
+	for name, tree := range trees {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".json"), []byte(tree), 0644))
+		suite := filepath.Join(dir, name+".test.json")
+		assert.NoError(t, ioutil.WriteFile(suite, []byte(`{"tree": "`+name+`.json", "cases": [{"name": "any", "request": {}, "expected_id": 1}]}`), 0644))
+
+		// Act
+		_, tr, err := LoadTestFile(suite)
+
+		// Assert
+		assert.True(t, errors.Is(err, ErrInvalidTree), name)
+		assert.Nil(t, tr, name)
+	}
+}
@@ -0,0 +1,555 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Split criteria used by Train
+const (
+	// InformationGain chooses the split that decreases the most the entropy (ID3)
+	InformationGain = "information_gain"
+	// GainRatio normalizes the information gain by the entropy of the split itself (C4.5)
+	GainRatio = "gain_ratio"
+)
+
+// Missing value strategies used by Train
+const (
+	// MissingLastPrediction keeps the samples without value for the key of a split on the split node,
+	// a request without the key stops on it and gets its majority outcome
+	MissingLastPrediction = "last_prediction"
+	// MissingFractional sends the samples without value for the key of a split down every child, with a
+	// weight proportional to the weight of the child (C4.5), the split node follows its heaviest child
+	// (DefaultChild) when a request has not the key
+	MissingFractional = "fractional"
+)
+
+// ErrNoSample : No sample was given to train the tree
+var ErrNoSample = errors.New("no sample")
+
+// ErrBadLabel : The label of a sample cannot be used to train the tree
+var ErrBadLabel = errors.New("label not supported")
+
+// Sample is a labeled request, used to train or evaluate a tree
+type Sample struct {
+	Request map[string]interface{} `json:"request"`
+	Label   interface{}            `json:"label"`
+}
+
+// TrainOptions allow to configure the training
+type TrainOptions struct {
+	// MaxDepth is the maximum number of conditions from the root to a leaf (0 means no limit)
+	MaxDepth int
+	// MinSamplesLeaf is the minimum number of samples on each branch of a split (1 by default)
+	MinSamplesLeaf int
+	// Criterion is InformationGain (by default) or GainRatio
+	Criterion string
+	// Features are the keys of the requests that can be used, all of them by default
+	Features []string
+	// Missing is MissingLastPrediction (by default) or MissingFractional
+	Missing string
+}
+
+// Train learns a classification tree from the samples (ID3 / C4.5).
+// Keys with string or bool values are split with one eq node per value, numeric keys with a lte and a gt node.
+// Each leaf holds the predicted class on its Content (and Name), and every condition node holds the
+// majority class of its samples on its Content, returned when no child matches the request
+// (by default the samples without a usable value for the key of a split stay on the split node,
+// see MissingFractional for the C4.5 strategy).
+// Every node holds the statistics of its samples on its Metadata: "samples", "impurity" and "distribution".
+// The labels must be strings, numbers or bools.
+func Train(samples []Sample, options ...func(o *TrainOptions)) (*Tree, error) {
+	config := &TrainOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	for _, s := range samples {
+		switch s.Label.(type) {
+		case string, float64, bool:
+		default:
+			return nil, ErrBadLabel
+		}
+	}
+
+	return induce(samples, config, classification{})
+}
+
+// trainRow is a sample with its weight
+type trainRow struct {
+	request map[string]interface{}
+	label   interface{}
+	weight  float64
+}
+
+// targetStats accumulates the labels of rows to compute the impurity of a node
+type targetStats interface {
+	add(r trainRow, sign float64)
+	weight() float64
+	impurity() float64
+}
+
+// learner abstracts what is learned by the tree induction
+type learner interface {
+	newStats() targetStats
+	// content returns the prediction stored on the nodes
+	content(rows []trainRow) interface{}
+	// name returns the name of the leaves
+	name(content interface{}) string
+}
+
+// classification learns the most frequent label
+type classification struct{}
+
+type classStats struct {
+	counts map[interface{}]float64
+	total  float64
+}
+
+func (classification) newStats() targetStats {
+	return &classStats{counts: make(map[interface{}]float64)}
+}
+
+func (s *classStats) add(r trainRow, sign float64) {
+	s.counts[r.label] += sign * r.weight
+	s.total += sign * r.weight
+}
+
+func (s *classStats) weight() float64 {
+	return s.total
+}
+
+// impurity is the entropy of the labels
+func (s *classStats) impurity() float64 {
+	if s.total <= 0 {
+		return 0
+	}
+
+	var e float64
+	for _, c := range s.counts {
+		if c > 0 {
+			p := c / s.total
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
+
+func (classification) content(rows []trainRow) interface{} {
+	return majority(rows)
+}
+
+func (classification) name(content interface{}) string {
+	return fmt.Sprint(content)
+}
+
+// majority returns the label with the biggest weight (the smallest one, as string, on equality)
+func majority(rows []trainRow) interface{} {
+	counts := make(map[interface{}]float64)
+	for _, r := range rows {
+		counts[r.label] += r.weight
+	}
+	return majorityOf(counts)
+}
+
+// majorityOf returns the label with the biggest weight (the smallest one, as string, on equality)
+func majorityOf(counts map[interface{}]float64) interface{} {
+	var best interface{}
+	bestCount := -1.0
+	for label, c := range counts {
+		if c > bestCount || (c == bestCount && fmt.Sprint(label) < fmt.Sprint(best)) {
+			best, bestCount = label, c
+		}
+	}
+	return best
+}
+
+// feature kinds
+const (
+	numericFeature     = "numeric"
+	categoricalFeature = "categorical"
+)
+
+// split is a candidate split of the rows of a node
+type split struct {
+	key       string
+	kind      string
+	threshold float64
+	values    []interface{}
+	parts     [][]trainRow
+	score     float64
+}
+
+// inducer builds a tree from rows
+type inducer struct {
+	config  *TrainOptions
+	learner learner
+	// features are the usable keys, with their kind
+	features []string
+	kinds    map[string]string
+	nodes    []Tree
+}
+
+// induce grows a tree from the samples, with the learner
+func induce(samples []Sample, config *TrainOptions, l learner) (*Tree, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+
+	if config.MinSamplesLeaf < 1 {
+		config.MinSamplesLeaf = 1
+	}
+	if config.Criterion == "" {
+		config.Criterion = InformationGain
+	}
+	if config.Criterion != InformationGain && config.Criterion != GainRatio {
+		return nil, fmt.Errorf("unknown criterion %q", config.Criterion)
+	}
+	if config.Missing == "" {
+		config.Missing = MissingLastPrediction
+	}
+	if config.Missing != MissingLastPrediction && config.Missing != MissingFractional {
+		return nil, fmt.Errorf("unknown missing value strategy %q", config.Missing)
+	}
+
+	rows := make([]trainRow, len(samples))
+	for i, s := range samples {
+		rows[i] = trainRow{request: s.Request, label: s.Label, weight: 1}
+	}
+
+	in := &inducer{
+		config:  config,
+		learner: l,
+		kinds:   featureKinds(samples, config.Features),
+	}
+	for k := range in.kinds {
+		in.features = append(in.features, k)
+	}
+	sort.Strings(in.features)
+
+	in.grow(rows, Tree{Name: "root"}, 0, 0, nil)
+
+	return CreateTree(in.nodes), nil
+}
+
+// featureKinds returns the kind of each key: numeric if all its values are numbers,
+// categorical if all its values are strings or bools, keys with mixed types are ignored
+func featureKinds(samples []Sample, features []string) map[string]string {
+	allowed := make(map[string]bool)
+	for _, f := range features {
+		allowed[f] = true
+	}
+
+	kinds := make(map[string]string)
+	mixed := make(map[string]bool)
+	for _, s := range samples {
+		for k, v := range s.Request {
+			if len(allowed) > 0 && !allowed[k] {
+				continue
+			}
+
+			var kind string
+			switch v.(type) {
+			case float64:
+				kind = numericFeature
+			case string, bool:
+				kind = categoricalFeature
+			case nil:
+				continue
+			default:
+				mixed[k] = true
+				continue
+			}
+
+			if existing, ok := kinds[k]; ok && existing != kind {
+				mixed[k] = true
+			}
+			kinds[k] = kind
+		}
+	}
+
+	for k := range mixed {
+		delete(kinds, k)
+	}
+
+	return kinds
+}
+
+// grow adds the node (condition or root) holding rows, then splits it or adds a leaf under it, and returns its id
+func (in *inducer) grow(rows []trainRow, node Tree, parentID int, depth int, usedCategorical map[string]bool) int {
+	content := in.learner.content(rows)
+	node.Content = content
+	node.Metadata = in.metadata(rows)
+	id := in.add(node, parentID)
+
+	var best *split
+	if in.config.MaxDepth == 0 || depth < in.config.MaxDepth {
+		best = in.bestSplit(rows, usedCategorical)
+	}
+
+	if best == nil {
+		in.add(Tree{Name: in.learner.name(content), Content: content, Metadata: in.metadata(rows)}, id)
+		return id
+	}
+
+	heaviest := -1
+	if in.config.Missing == MissingFractional {
+		heaviest = distributeMissing(rows, best)
+	}
+
+	var children []int
+	if best.kind == numericFeature {
+		children = append(children,
+			in.grow(best.parts[0], Tree{Key: best.key, Operator: "lte", Value: best.threshold, Order: 1}, id, depth+1, usedCategorical),
+			in.grow(best.parts[1], Tree{Key: best.key, Operator: "gt", Value: best.threshold, Order: 2}, id, depth+1, usedCategorical))
+	} else {
+		used := make(map[string]bool, len(usedCategorical)+1)
+		for k := range usedCategorical {
+			used[k] = true
+		}
+		used[best.key] = true
+
+		for i, v := range best.values {
+			children = append(children, in.grow(best.parts[i], Tree{Key: best.key, Operator: "eq", Value: v, Order: i + 1}, id, depth+1, used))
+		}
+	}
+
+	if heaviest >= 0 {
+		in.nodes[id-1].DefaultChild = children[heaviest]
+	}
+	return id
+}
+
+// distributeMissing adds the rows without a usable value for the key of the split to every part,
+// with a weight proportional to the weight of the part (C4.5 fractional instances).
+// It returns the index of the heaviest part.
+func distributeMissing(rows []trainRow, s *split) int {
+	weights := make([]float64, len(s.parts))
+	var known float64
+	heaviest := 0
+	for i, p := range s.parts {
+		for _, r := range p {
+			weights[i] += r.weight
+		}
+		known += weights[i]
+		if weights[i] > weights[heaviest] {
+			heaviest = i
+		}
+	}
+
+	for _, r := range rows {
+		v := r.request[s.key]
+		_, numeric := v.(float64)
+		_, text := v.(string)
+		_, boolean := v.(bool)
+		if (s.kind == numericFeature && numeric) || (s.kind == categoricalFeature && (text || boolean)) {
+			continue
+		}
+
+		for i := range s.parts {
+			if weights[i] > 0 {
+				s.parts[i] = append(s.parts[i], trainRow{request: r.request, label: r.label, weight: r.weight * weights[i] / known})
+			}
+		}
+	}
+
+	return heaviest
+}
+
+// metadata returns the statistics of the rows of a node: the "samples" (their weight), the "impurity"
+// and, for classification, the "distribution" (weight of each label)
+func (in *inducer) metadata(rows []trainRow) map[string]interface{} {
+	stats := in.learner.newStats()
+	for _, r := range rows {
+		stats.add(r, 1)
+	}
+	return statsMetadata(stats)
+}
+
+// statsMetadata returns the Metadata of a node holding samples with the stats
+func statsMetadata(stats targetStats) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"samples":  stats.weight(),
+		"impurity": stats.impurity(),
+	}
+	if s, ok := stats.(*classStats); ok {
+		distribution := make(map[string]interface{}, len(s.counts))
+		for label, c := range s.counts {
+			distribution[classKey(label)] = c
+		}
+		metadata["distribution"] = distribution
+	}
+
+	return metadata
+}
+
+func (in *inducer) add(node Tree, parentID int) int {
+	node.ID = len(in.nodes) + 1
+	node.ParentID = parentID
+	in.nodes = append(in.nodes, node)
+	return node.ID
+}
+
+// bestSplit returns the split with the best score, nil if no split improves the node
+func (in *inducer) bestSplit(rows []trainRow, usedCategorical map[string]bool) *split {
+	stats := in.learner.newStats()
+	for _, r := range rows {
+		stats.add(r, 1)
+	}
+
+	if stats.impurity() <= 1e-12 || stats.weight() < 2*float64(in.config.MinSamplesLeaf) {
+		return nil
+	}
+
+	var best *split
+	for _, key := range in.features {
+		var s *split
+		if in.kinds[key] == numericFeature {
+			s = in.numericSplit(rows, key, stats.weight())
+		} else if !usedCategorical[key] {
+			s = in.categoricalSplit(rows, key, stats.weight())
+		}
+
+		if s != nil && s.score > 1e-12 && (best == nil || s.score > best.score) {
+			best = s
+		}
+	}
+
+	return best
+}
+
+// score computes the criterion of a split: the decrease of impurity of the rows having the key,
+// weighted by their part of the node, and divided by the split information for GainRatio
+func (in *inducer) score(total float64, known targetStats, parts []targetStats) float64 {
+	if known.weight() <= 0 {
+		return 0
+	}
+
+	var children, splitInfo float64
+	for _, p := range parts {
+		w := p.weight() / known.weight()
+		children += w * p.impurity()
+		if w > 0 {
+			splitInfo -= w * math.Log2(w)
+		}
+	}
+
+	gain := (known.weight() / total) * (known.impurity() - children)
+	if in.config.Criterion == GainRatio {
+		if splitInfo <= 1e-12 {
+			return 0
+		}
+		return gain / splitInfo
+	}
+	return gain
+}
+
+// numericSplit searches the best threshold of a numeric key
+func (in *inducer) numericSplit(rows []trainRow, key string, total float64) *split {
+	var known []trainRow
+	for _, r := range rows {
+		if _, ok := r.request[key].(float64); ok {
+			known = append(known, r)
+		}
+	}
+
+	if len(known) < 2 {
+		return nil
+	}
+
+	sort.SliceStable(known, func(i, j int) bool {
+		return known[i].request[key].(float64) < known[j].request[key].(float64)
+	})
+
+	knownStats := in.learner.newStats()
+	for _, r := range known {
+		knownStats.add(r, 1)
+	}
+
+	left := in.learner.newStats()
+	right := in.learner.newStats()
+	for _, r := range known {
+		right.add(r, 1)
+	}
+
+	var best *split
+	min := float64(in.config.MinSamplesLeaf)
+	for i := 0; i < len(known)-1; i++ {
+		left.add(known[i], 1)
+		right.add(known[i], -1)
+
+		v, next := known[i].request[key].(float64), known[i+1].request[key].(float64)
+		if v == next || left.weight() < min || right.weight() < min {
+			continue
+		}
+
+		score := in.score(total, knownStats, []targetStats{left, right})
+		if best == nil || score > best.score {
+			best = &split{key: key, kind: numericFeature, threshold: (v + next) / 2, score: score}
+		}
+	}
+
+	if best != nil {
+		best.parts = [][]trainRow{nil, nil}
+		for _, r := range known {
+			if r.request[key].(float64) <= best.threshold {
+				best.parts[0] = append(best.parts[0], r)
+			} else {
+				best.parts[1] = append(best.parts[1], r)
+			}
+		}
+	}
+
+	return best
+}
+
+// categoricalSplit splits the rows by value of the key
+func (in *inducer) categoricalSplit(rows []trainRow, key string, total float64) *split {
+	byValue := make(map[interface{}][]trainRow)
+	stats := make(map[interface{}]targetStats)
+	known := in.learner.newStats()
+	for _, r := range rows {
+		v, ok := r.request[key]
+		switch v.(type) {
+		case string, bool:
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+
+		if _, exists := stats[v]; !exists {
+			stats[v] = in.learner.newStats()
+		}
+		byValue[v] = append(byValue[v], r)
+		stats[v].add(r, 1)
+		known.add(r, 1)
+	}
+
+	if len(byValue) < 2 {
+		return nil
+	}
+
+	s := &split{key: key, kind: categoricalFeature}
+	for v := range byValue {
+		s.values = append(s.values, v)
+	}
+	sort.Slice(s.values, func(i, j int) bool {
+		return fmt.Sprint(s.values[i]) < fmt.Sprint(s.values[j])
+	})
+
+	var parts []targetStats
+	for _, v := range s.values {
+		if stats[v].weight() < float64(in.config.MinSamplesLeaf) {
+			return nil
+		}
+		s.parts = append(s.parts, byValue[v])
+		parts = append(parts, stats[v])
+	}
+
+	s.score = in.score(total, known, parts)
+	return s
+}
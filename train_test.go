@@ -0,0 +1,196 @@
+package dtree
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadSamples(t *testing.T, path string) []Sample {
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	var samples []Sample
+	assert.NoError(t, json.Unmarshal(b, &samples))
+	return samples
+}
+
+func TestTrain(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	for _, criterion := range []string{InformationGain, GainRatio} {
+		// Act
+		tr, err := Train(samples, func(o *TrainOptions) {
+			o.Criterion = criterion
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		for _, s := range samples {
+			node, err := tr.Resolve(s.Request)
+			assert.NoError(t, err)
+			assert.Equal(t, s.Label, node.Content, "%s: a tree without limit should learn every sample", criterion)
+			assert.Empty(t, node.GetChild(), "the resolution should end on a leaf")
+		}
+	}
+}
+
+func TestTrain_Splits_On_Outlook_First(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	tr, err := Train(samples, func(o *TrainOptions) {
+		o.Features = []string{"outlook", "windy", "humidity"}
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "root", tr.Name)
+	assert.Equal(t, "yes", tr.Content, "the root holds the majority class")
+	if assert.Len(t, tr.GetChild(), 3) {
+		for i, v := range []string{"overcast", "rain", "sunny"} {
+			assert.Equal(t, "outlook", tr.GetChild()[i].Key)
+			assert.Equal(t, "eq", tr.GetChild()[i].Operator)
+			assert.Equal(t, v, tr.GetChild()[i].Value)
+		}
+		overcast := tr.GetChild()[0].GetChild()
+		if assert.Len(t, overcast, 1) {
+			assert.Equal(t, "yes", overcast[0].Name)
+		}
+	}
+
+	// an unknown value stops on the root, with its majority class
+	node, _ := tr.Resolve(map[string]interface{}{"outlook": "snow"})
+	assert.Equal(t, "yes", node.Content)
+}
+
+func TestTrain_Options(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	stump, err := Train(samples, func(o *TrainOptions) {
+		o.MaxDepth = 1
+	})
+	big, err2 := Train(samples, func(o *TrainOptions) {
+		o.MinSamplesLeaf = 7
+		o.Features = []string{"humidity"}
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	for _, n := range stump.GetChild() {
+		if assert.Len(t, n.GetChild(), 1, "a depth 1 tree has leaves under the first split") {
+			assert.Empty(t, n.GetChild()[0].GetChild())
+		}
+	}
+	if assert.Len(t, big.GetChild(), 2) {
+		assert.Equal(t, "lte", big.GetChild()[0].Operator)
+		assert.Equal(t, 82.5, big.GetChild()[0].Value)
+		assert.Equal(t, "gt", big.GetChild()[1].Operator)
+	}
+}
+
+func TestTrain_Missing(t *testing.T) {
+	// Arrange
+	var samples []Sample
+	for i := 0; i < 6; i++ {
+		samples = append(samples, Sample{Request: map[string]interface{}{"plan": "premium"}, Label: "yes"})
+	}
+	for i := 0; i < 3; i++ {
+		samples = append(samples, Sample{Request: map[string]interface{}{"plan": "basic"}, Label: "no"})
+	}
+	samples = append(samples,
+		Sample{Request: map[string]interface{}{}, Label: "yes"},
+		Sample{Request: map[string]interface{}{"plan": nil}, Label: "no"})
+
+	// Act
+	lastPrediction, err := Train(samples)
+	assert.NoError(t, err)
+	fractional, err := Train(samples, func(o *TrainOptions) {
+		o.Missing = MissingFractional
+	})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 0, lastPrediction.DefaultChild)
+	node, err := lastPrediction.Resolve(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, lastPrediction, node, "the request without plan stops on the split")
+
+	premium := fractional.GetChild()[1]
+	assert.Equal(t, "premium", premium.Value)
+	assert.Equal(t, premium.ID, fractional.DefaultChild, "the heaviest child is the default one")
+	assert.InDelta(t, 6+2*6.0/9, premium.Metadata["samples"], 1e-9)
+	assert.InDelta(t, 3+2*3.0/9, fractional.GetChild()[0].Metadata["samples"], 1e-9)
+
+	node, err = fractional.Resolve(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", node.Content)
+	assert.Equal(t, premium, node.GetParent())
+
+	_, err = Train(samples, func(o *TrainOptions) {
+		o.Missing = "surrogate"
+	})
+	assert.Error(t, err)
+}
+
+func TestTrain_Errors(t *testing.T) {
+	_, err := Train(nil)
+	assert.Equal(t, ErrNoSample, err)
+
+	_, err = Train([]Sample{{Request: map[string]interface{}{"a": 1.0}, Label: []interface{}{"x"}}})
+	assert.Equal(t, ErrBadLabel, err)
+
+	_, err = Train([]Sample{{Label: "x"}}, func(o *TrainOptions) { o.Criterion = "gini" })
+	assert.Error(t, err)
+}
+
+func TestMarshalTree(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+	tr, err := Train(samples)
+	assert.NoError(t, err)
+
+	// Act
+	b, err := MarshalTree(tr)
+	assert.NoError(t, err)
+	loaded, err := LoadTree(b)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, tr.String(), loaded.String())
+	for _, s := range samples {
+		node, _ := loaded.Resolve(s.Request)
+		assert.Equal(t, s.Label, node.Content)
+	}
+	assert.Equal(t, 0, tr.GetChild()[0].Flatten()[0].ParentID, "the flattened node becomes the root")
+}
+
+func TestMarshalTree_AddNode(t *testing.T) {
+	// Arrange
+	root := &Tree{ID: 1, Name: "root"}
+	a := &Tree{ID: 2, Name: "A", Key: "a", Operator: "eq", Value: true, Order: 1}
+	b := &Tree{ID: 3, Name: "B", Key: "a", Operator: "eq", Value: false, Order: 2}
+	root.AddNode(a)
+	root.AddNode(b)
+	b.AddNode(&Tree{ID: 4, Name: "C", Key: "c", Operator: "gt", Value: 1.0})
+
+	// Act
+	bytes, err := MarshalTree(root)
+	assert.NoError(t, err)
+	loaded, err := LoadTree(bytes)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, root.String(), loaded.String())
+	node, err := loaded.Resolve(map[string]interface{}{"a": false, "c": 2.0})
+	assert.NoError(t, err)
+	assert.Equal(t, "C", node.Name)
+	assert.Len(t, root.Clone().FindByID(3).GetChild(), 1)
+}
@@ -0,0 +1,197 @@
+package dtree
+
+import (
+	"errors"
+)
+
+// ErrSkipChildren : returned by the function given to Walk, the children of the node are not visited
+var ErrSkipChildren = errors.New("skip children")
+
+// ErrStopWalk : returned by the function given to Walk or WalkPostOrder, no other node is visited
+var ErrStopWalk = errors.New("stop walk")
+
+// NodeQuery selects nodes, its empty fields match every node
+type NodeQuery struct {
+	Key string
+	// Operator matches its short and long names (gt and >)
+	Operator string
+	Value    interface{}
+	Name     string
+	// Match is an additional condition
+	Match func(n *Tree) bool
+}
+
+// treeIndex is the index by id of the nodes of a tree, shared by its nodes
+type treeIndex map[int]*Tree
+
+// indexTree indexes the node and its descendants on index (the first node of an id is kept),
+// a nil index removes their index
+func indexTree(t *Tree, index treeIndex) {
+	t.Walk(func(n *Tree) error {
+		n.index = index
+		if _, ok := index[n.ID]; !ok && index != nil {
+			index[n.ID] = n
+		}
+		return nil
+	})
+}
+
+// unindexTree removes the node and its descendants from the index of their tree, then indexes them
+// on a new one as a detached tree
+func unindexTree(t *Tree) {
+	if index := t.index; index != nil {
+		t.Walk(func(n *Tree) error {
+			if index[n.ID] == n {
+				delete(index, n.ID)
+			}
+			return nil
+		})
+	}
+
+	indexTree(t, make(treeIndex))
+}
+
+// Walk visits the node and its descendants depth first, each node before its children (pre-order).
+// fn can return ErrSkipChildren to not visit the children of a node, ErrStopWalk to stop the walk, or any
+// other error which stops the walk and is returned.
+func (t *Tree) Walk(fn func(n *Tree) error) error {
+	err := t.walk(fn)
+	if err == ErrStopWalk {
+		return nil
+	}
+	return err
+}
+
+func (t *Tree) walk(fn func(n *Tree) error) error {
+	err := fn(t)
+	if err == ErrSkipChildren {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, child := range t.nodes {
+		if err := child.walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkPostOrder visits the node and its descendants depth first, each node after its children.
+// fn can return ErrStopWalk to stop the walk, or any other error which stops the walk and is returned.
+func (t *Tree) WalkPostOrder(fn func(n *Tree) error) error {
+	err := t.walkPostOrder(fn)
+	if err == ErrStopWalk {
+		return nil
+	}
+	return err
+}
+
+func (t *Tree) walkPostOrder(fn func(n *Tree) error) error {
+	for _, child := range t.nodes {
+		if err := child.walkPostOrder(fn); err != nil {
+			return err
+		}
+	}
+
+	return fn(t)
+}
+
+// FindByID returns the node (this one or a descendant) with the id, nil if there is none.
+// The nodes are indexed by CreateTree, AddNode and RemoveNode: the lookup on a root does not visit
+// the tree, the one on a subtree only visits it when the id is not one of its indexed nodes.
+func (t *Tree) FindByID(id int) *Tree {
+	if t.index != nil {
+		if n, ok := t.index[id]; ok && t.isAncestorOf(n) {
+			return n
+		}
+		if t.parent == nil {
+			return nil
+		}
+	}
+
+	var found *Tree
+	t.Walk(func(n *Tree) error {
+		if n.ID == id {
+			found = n
+			return ErrStopWalk
+		}
+		return nil
+	})
+	return found
+}
+
+// isAncestorOf returns if the node is n or one of its ancestors
+func (t *Tree) isAncestorOf(n *Tree) bool {
+	for ; n != nil; n = n.parent {
+		if n == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FindByName returns the nodes (this one and its descendants) with the name, in pre-order
+func (t *Tree) FindByName(name string) []*Tree {
+	return t.Find(NodeQuery{Name: name})
+}
+
+// Find returns the nodes (this one and its descendants) selected by the query, in pre-order
+func (t *Tree) Find(query NodeQuery) []*Tree {
+	var nodes []*Tree
+	t.Walk(func(n *Tree) error {
+		if query.Key != "" && n.Key != query.Key {
+			return nil
+		}
+		if query.Operator != "" && canonicalOperator(n.Operator) != canonicalOperator(query.Operator) {
+			return nil
+		}
+		if query.Value != nil && !sameValue(n.Value, query.Value) {
+			return nil
+		}
+		if query.Name != "" && n.Name != query.Name {
+			return nil
+		}
+		if query.Match != nil && !query.Match(n) {
+			return nil
+		}
+
+		nodes = append(nodes, n)
+		return nil
+	})
+	return nodes
+}
+
+// Leaves returns the nodes without child (this one and its descendants), in pre-order
+func (t *Tree) Leaves() []*Tree {
+	return t.Find(NodeQuery{Match: func(n *Tree) bool {
+		return len(n.nodes) == 0
+	}})
+}
+
+// PathToRoot returns the node and its ancestors, the root being the last
+func (t *Tree) PathToRoot() []*Tree {
+	var path []*Tree
+	for n := t; n != nil; n = n.parent {
+		path = append(path, n)
+	}
+	return path
+}
+
+// Depth returns the number of ancestors of the node (0 for the root)
+func (t *Tree) Depth() int {
+	return len(t.PathToRoot()) - 1
+}
+
+// Height returns the number of nodes between the node and its deepest descendant (0 for a leaf)
+func (t *Tree) Height() int {
+	height := 0
+	for _, child := range t.nodes {
+		if h := child.Height() + 1; h > height {
+			height = h
+		}
+	}
+	return height
+}
@@ -0,0 +1,126 @@
+package dtree
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadHello(t *testing.T) *Tree {
+	b, err := ioutil.ReadFile("testdata/hello.json")
+	assert.NoError(t, err)
+	tr, err := LoadTree(b)
+	assert.NoError(t, err)
+	return tr
+}
+
+func ids(nodes []*Tree) []int {
+	result := []int{}
+	for _, n := range nodes {
+		result = append(result, n.ID)
+	}
+	return result
+}
+
+func TestTree_Walk(t *testing.T) {
+	// Arrange
+	tr := CreateTree([]Tree{
+		{ID: 1, Name: "root"},
+		{ID: 2, ParentID: 1, Order: 1},
+		{ID: 3, ParentID: 2},
+		{ID: 4, ParentID: 1, Order: 2},
+		{ID: 5, ParentID: 4},
+	})
+
+	// Act
+	var pre, skipped, stopped, post []*Tree
+	errPre := tr.Walk(func(n *Tree) error {
+		pre = append(pre, n)
+		return nil
+	})
+	tr.Walk(func(n *Tree) error {
+		skipped = append(skipped, n)
+		if n.ID == 2 {
+			return ErrSkipChildren
+		}
+		return nil
+	})
+	errStop := tr.Walk(func(n *Tree) error {
+		stopped = append(stopped, n)
+		if n.ID == 3 {
+			return ErrStopWalk
+		}
+		return nil
+	})
+	tr.WalkPostOrder(func(n *Tree) error {
+		post = append(post, n)
+		return nil
+	})
+	errOther := tr.WalkPostOrder(func(n *Tree) error {
+		return errors.New("failed")
+	})
+
+	// Assert
+	assert.NoError(t, errPre)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids(pre))
+	assert.Equal(t, []int{1, 2, 4, 5}, ids(skipped))
+	assert.NoError(t, errStop)
+	assert.Equal(t, []int{1, 2, 3}, ids(stopped))
+	assert.Equal(t, []int{3, 2, 5, 4, 1}, ids(post))
+	assert.EqualError(t, errOther, "failed")
+}
+
+func TestTree_FindByID(t *testing.T) {
+	// Arrange
+	tr := loadHello(t)
+	gender := tr.FindByID(9)
+
+	// Act
+	added := &Tree{ID: 14, ParentID: 9, Name: "Hello you"}
+	gender.AddNode(added)
+	removed := tr.FindByID(5)
+	tr.FindByID(2).RemoveNode(removed)
+
+	// Assert
+	assert.Equal(t, "M", gender.Value)
+	assert.Equal(t, added, tr.FindByID(14))
+	assert.Nil(t, tr.FindByID(5), "the removed nodes are not found anymore")
+	assert.Nil(t, tr.FindByID(6))
+	assert.Equal(t, removed.GetChild()[0], removed.FindByID(6))
+	assert.Nil(t, gender.FindByID(4), "only the descendants are found")
+	assert.Nil(t, tr.FindByID(99))
+	assert.Equal(t, added, tr.index[14], "the added nodes are indexed")
+	assert.NotContains(t, tr.index, 5, "the removed nodes are not indexed anymore")
+	assert.Equal(t, removed, removed.index[5], "the removed nodes are indexed as a new tree")
+
+	assert.NoError(t, tr.MoveNode(tr.FindByID(12)))
+	assert.Equal(t, tr, tr.FindByID(12).GetParent())
+	assert.Equal(t, "Hello dude", tr.FindByID(13).Name)
+
+	copied := CreateTree(tr.Flatten())
+	assert.True(t, tr.FindByID(13) != copied.FindByID(13), "the copy has its own nodes")
+	assert.Equal(t, "Hello dude", copied.FindByID(13).Name)
+}
+
+func TestTree_Find(t *testing.T) {
+	tr := loadHello(t)
+
+	assert.ElementsMatch(t, []int{5, 9}, ids(tr.Find(NodeQuery{Key: "gender"})))
+	assert.Equal(t, []int{10}, ids(tr.Find(NodeQuery{Key: "age", Operator: ">"})))
+	assert.Equal(t, []int{3}, ids(tr.Find(NodeQuery{Key: "sayHello", Value: false})))
+	assert.Equal(t, []int{8}, ids(tr.FindByName("Hello")))
+	assert.ElementsMatch(t, []int{4, 6, 8, 11, 13}, ids(tr.Leaves()))
+}
+
+func TestTree_Path_Depth_Height(t *testing.T) {
+	tr := loadHello(t)
+	sir := tr.FindByID(11)
+
+	assert.Equal(t, []int{11, 10, 9, 2, 1}, ids(sir.PathToRoot()))
+	assert.Equal(t, 4, sir.Depth())
+	assert.Equal(t, 0, tr.Depth())
+	assert.Equal(t, 4, tr.Height())
+	assert.Equal(t, 0, sir.Height())
+}
@@ -18,13 +18,20 @@ type TreeOptions struct {
 	StopIfConvertingError    bool
 	Operators                map[string]Operator
 	OverrideExistingOperator bool
-	context                  context.Context
+	// OnEvaluate is called each time a node is evaluated during the resolution,
+	// with the node selected (nil if none) and the error of the comparison
+	OnEvaluate func(node *Tree, selected *Tree, err error)
+	// OnResolve is called once at the end of each resolution, with the node on which it started,
+	// the node selected and the error returned
+	OnResolve func(root *Tree, selected *Tree, err error)
+	context   context.Context
 }
 
 // Tree represents a Tree
 type Tree struct {
 	nodes  []*Tree
 	parent *Tree
+	index  treeIndex
 
 	treeDrawer *drawer.Tree
 
@@ -39,6 +46,12 @@ type Tree struct {
 	Order    int                    `json:"order"`
 	Content  interface{}            `json:"content"`
 	Headers  map[string]interface{} `json:"headers"`
+	// DefaultChild is the id of the child followed when the key compared by a child is missing
+	// from the request (absent or null), instead of evaluating the next children
+	DefaultChild int `json:"default_child,omitempty"`
+	// Metadata holds informations about the node which are not used by the resolution
+	// (number of samples, distribution of the labels...)
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type byOrder []*Tree
@@ -67,6 +80,10 @@ func (t *Tree) AddNode(node *Tree) {
 	node.parent = t
 	t.nodes = append(t.nodes, node)
 	sort.Sort(byOrder(t.nodes))
+
+	if t.index != nil || node.index != nil {
+		indexTree(node, t.index)
+	}
 }
 
 // GetChild get the nodes child of this one
@@ -101,7 +118,18 @@ func (t *Tree) Next(jsonRequest map[string]interface{}, config *TreeOptions) (*T
 			oldName = n.Key
 		}
 
-		selected, err := compare(jsonRequest, jsonValue, n, config)
+		var selected *Tree
+		var err error
+		if d := t.defaultChild(); d != nil && jsonValue == nil && isCondition(n) {
+			// the key is missing, the default child is followed
+			selected = d
+		} else {
+			selected, err = compare(jsonRequest, jsonValue, n, config)
+		}
+		if config.OnEvaluate != nil {
+			config.OnEvaluate(n, selected, err)
+		}
+
 		if config.StopIfConvertingError == true && err != nil {
 			return n, err
 		}
@@ -121,6 +149,19 @@ func (t *Tree) Next(jsonRequest map[string]interface{}, config *TreeOptions) (*T
 	return nil, nil
 }
 
+// defaultChild returns the child whose id is DefaultChild, nil if there is none
+func (t *Tree) defaultChild() *Tree {
+	if t.DefaultChild == 0 {
+		return nil
+	}
+	for _, n := range t.nodes {
+		if n.ID == t.DefaultChild {
+			return n
+		}
+	}
+	return nil
+}
+
 // LoadTree gets a json on build the Tree related
 func LoadTree(jsonTree []byte) (*Tree, error) {
 	var trees []Tree
@@ -132,12 +173,51 @@ func LoadTree(jsonTree []byte) (*Tree, error) {
 	return CreateTree(trees), nil
 }
 
+// MarshalTree encodes the tree on the json format read by LoadTree
+func MarshalTree(t *Tree) ([]byte, error) {
+	return json.Marshal(t.Flatten())
+}
+
+// Flatten returns the nodes of the tree (depth first), on the format used by CreateTree.
+// The node on which it is called becomes the root (its ParentID is 0)
+func (t *Tree) Flatten() []Tree {
+	var nodes []Tree
+	var visit func(n *Tree)
+	visit = func(n *Tree) {
+		// the ParentID field is not kept up to date by AddNode, the parent is
+		parentID := 0
+		if n != t && n.parent != nil {
+			parentID = n.parent.ID
+		}
+		nodes = append(nodes, Tree{
+			ID:           n.ID,
+			Name:         n.Name,
+			ParentID:     parentID,
+			Value:        n.Value,
+			Operator:     n.Operator,
+			Key:          n.Key,
+			Order:        n.Order,
+			Content:      n.Content,
+			Headers:      n.Headers,
+			DefaultChild: n.DefaultChild,
+			Metadata:     n.Metadata,
+		})
+		for _, child := range n.GetChild() {
+			visit(child)
+		}
+	}
+	visit(t)
+
+	return nodes
+}
+
 // CreateTree attach the nodes to the Tree
 func CreateTree(data []Tree) *Tree {
 	temp := make(map[int]*Tree)
 	var root *Tree
 	for i := range data {
 		leaf := &data[i]
+		leaf.index = nil
 		temp[leaf.ID] = leaf
 		if leaf.ParentID == 0 {
 			root = leaf
@@ -150,6 +230,10 @@ func CreateTree(data []Tree) *Tree {
 		}
 	}
 
+	if root != nil {
+		indexTree(root, make(treeIndex, len(data)))
+	}
+
 	return root
 }
 
@@ -166,22 +250,7 @@ func (t *Tree) ResolveJSON(jsonRequest []byte, options ...func(t *TreeOptions))
 
 // Resolve calculate which will be the selected node according to the map request
 func (t *Tree) Resolve(request map[string]interface{}, options ...func(t *TreeOptions)) (*Tree, error) {
-	config := &TreeOptions{}
-
-	for _, option := range options {
-		option(config)
-	}
-
-	if len(config.Operators) > 0 {
-		for k := range config.Operators {
-			if isExistingOperator(k) {
-				config.OverrideExistingOperator = true
-				break
-			}
-		}
-	}
-
-	return t.resolve(request, config)
+	return t.resolveRequest(request, newTreeOptions(&TreeOptions{}, options...))
 }
 
 // ResolveJSONWithContext calculate which will be the selected node according to the jsonRequest
@@ -197,10 +266,16 @@ func (t *Tree) ResolveJSONWithContext(ctx context.Context, jsonRequest []byte, o
 
 // ResolveWithContext calculate which will be the selected node according to the map request
 func (t *Tree) ResolveWithContext(ctx context.Context, request map[string]interface{}, options ...func(t *TreeOptions)) (*Tree, context.Context, error) {
-	config := &TreeOptions{
+	config := newTreeOptions(&TreeOptions{
 		context: ctx,
-	}
+	}, options...)
+
+	result, err := t.resolveRequest(request, config)
+	return result, config.context, err
+}
 
+// newTreeOptions applies the options on config
+func newTreeOptions(config *TreeOptions, options ...func(t *TreeOptions)) *TreeOptions {
 	for _, option := range options {
 		option(config)
 	}
@@ -214,8 +289,16 @@ func (t *Tree) ResolveWithContext(ctx context.Context, request map[string]interf
 		}
 	}
 
+	return config
+}
+
+// resolveRequest resolves the request from this node, then calls OnResolve
+func (t *Tree) resolveRequest(request map[string]interface{}, config *TreeOptions) (*Tree, error) {
 	result, err := t.resolve(request, config)
-	return result, config.context, err
+	if config.OnResolve != nil {
+		config.OnResolve(t, result, err)
+	}
+	return result, err
 }
 
 func (t *Tree) resolve(request map[string]interface{}, config *TreeOptions) (*Tree, error) {
@@ -231,16 +314,55 @@ func (t *Tree) resolve(request map[string]interface{}, config *TreeOptions) (*Tr
 }
 
 func (t *Tree) String() string {
+	return drawTree(t, drawLabel)
+}
+
+// drawLabel draws the node, with its number of samples and their distribution when they are known
+func drawLabel(t *Tree) string {
+	label := t.ValueToDraw()
+	samples, ok := t.Metadata["samples"].(float64)
+	if !ok {
+		return label
+	}
+
+	label = fmt.Sprintf("%s\nsamples %g", label, samples)
+	distribution, ok := t.Metadata["distribution"].(map[string]interface{})
+	if !ok || len(distribution) == 0 {
+		return label
+	}
+
+	classes := make([]string, 0, len(distribution))
+	for c := range distribution {
+		classes = append(classes, c)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		ci, _ := distribution[classes[i]].(float64)
+		cj, _ := distribution[classes[j]].(float64)
+		if ci != cj {
+			return ci > cj
+		}
+		return classes[i] < classes[j]
+	})
+
+	parts := make([]string, len(classes))
+	for i, c := range classes {
+		parts[i] = fmt.Sprintf("%s %v", c, distribution[c])
+	}
+	return label + ": " + strings.Join(parts, ", ")
+}
+
+// drawTree draws the tree, with label giving the text of each node
+func drawTree(t *Tree, label func(t *Tree) string) string {
 	d := drawer.NewTree(drawer.NodeString(""))
-	buildDrawerTree(t, d)
+	buildDrawerTree(t, d, label)
 	return d.String()
 }
 
-func buildDrawerTree(t *Tree, d *drawer.Tree) {
-	d.SetVal(drawer.NodeString(t.ValueToDraw()))
+func buildDrawerTree(t *Tree, d *drawer.Tree, label func(t *Tree) string) {
+	d.SetVal(drawer.NodeString(label(t)))
 	for i := range t.GetChild() {
 		tChild := d.AddChild(drawer.NodeString(""))
-		buildDrawerTree(t.GetChild()[i], tChild)
+		buildDrawerTree(t.GetChild()[i], tChild, label)
 	}
 }
 
@@ -278,3 +278,51 @@ func ExampleLoadTree() {
 	fmt.Println(v.Name)
 	// output : Hello dude
 }
+
+func TestTree_DefaultChild(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root", "default_child": 3},
+		{"id": 2, "parent_id": 1, "key": "age", "operator": "lt", "value": 18, "order": 1},
+		{"id": 3, "parent_id": 1, "key": "age", "operator": "gte", "value": 18, "order": 2},
+		{"id": 4, "parent_id": 1, "value": "fallback"}
+	]`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		request  string
+		expected int
+	}{
+		{`{"age": 10}`, 2},
+		{`{"age": 20}`, 3},
+		{`{}`, 3},
+		{`{"age": null}`, 3},
+		{`{"age": "20"}`, 4},
+	}
+	for _, test := range tests {
+		// Act
+		result, err := tr.ResolveJSON([]byte(test.request))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result.ID, test.request)
+	}
+}
+
+func TestTree_Without_DefaultChild(t *testing.T) {
+	// Arrange
+	tr, err := LoadTree([]byte(`[
+		{"id": 1, "name": "root"},
+		{"id": 2, "parent_id": 1, "key": "age", "operator": "lt", "value": 18, "order": 1},
+		{"id": 3, "parent_id": 1, "key": "age", "operator": "gte", "value": 18, "order": 2},
+		{"id": 4, "parent_id": 1, "value": "fallback"}
+	]`))
+	assert.NoError(t, err)
+
+	// Act
+	result, err := tr.ResolveJSON([]byte(`{}`))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.ID, "without default child, the comparisons fail and the fallback is selected")
+}
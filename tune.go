@@ -0,0 +1,244 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ErrFolds : The number of folds must be at least 2 and at most the number of samples
+var ErrFolds = errors.New("invalid number of folds")
+
+// CrossValidationOptions allow to configure a k-fold cross validation
+type CrossValidationOptions struct {
+	// Folds is the number of parts of the samples (5 by default)
+	Folds int
+	// Seed makes the split of the samples reproducible
+	Seed int64
+	// Regression trains regression trees (TrainRegression) scored with the R2, instead of
+	// classification ones scored with the accuracy
+	Regression bool
+}
+
+// CrossValidation is the score of a training configuration on each fold
+type CrossValidation struct {
+	Scores []float64 `json:"scores"`
+	Mean   float64   `json:"mean"`
+	StdDev float64   `json:"std_dev"`
+}
+
+// CrossValidate splits the samples in folds, then for each one trains a tree on the other folds
+// and scores it on the fold. For classification the folds keep the proportion of each label.
+func CrossValidate(samples []Sample, train TrainOptions, options ...func(o *CrossValidationOptions)) (*CrossValidation, error) {
+	config := &CrossValidationOptions{}
+	for _, option := range options {
+		option(config)
+	}
+	if config.Folds == 0 {
+		config.Folds = 5
+	}
+
+	folds, err := splitFolds(samples, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return crossValidate(samples, folds, train, config)
+}
+
+// splitFolds returns the fold of each sample
+func splitFolds(samples []Sample, config *CrossValidationOptions) ([]int, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSample
+	}
+	if config.Folds < 2 || config.Folds > len(samples) {
+		return nil, ErrFolds
+	}
+
+	random := rand.New(rand.NewSource(config.Seed))
+
+	groups := make(map[string][]int)
+	for i, s := range samples {
+		key := ""
+		if !config.Regression {
+			key = classKey(s.Label)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var keys []string
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	folds := make([]int, len(samples))
+	position := 0
+	for _, k := range keys {
+		indexes := groups[k]
+		random.Shuffle(len(indexes), func(i, j int) {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		})
+		for _, i := range indexes {
+			folds[i] = position % config.Folds
+			position++
+		}
+	}
+
+	return folds, nil
+}
+
+func crossValidate(samples []Sample, folds []int, train TrainOptions, config *CrossValidationOptions) (*CrossValidation, error) {
+	cv := &CrossValidation{}
+	for fold := 0; fold < config.Folds; fold++ {
+		var training, test []Sample
+		for i, s := range samples {
+			if folds[i] == fold {
+				test = append(test, s)
+			} else {
+				training = append(training, s)
+			}
+		}
+
+		t, err := trainWith(training, train, config.Regression)
+		if err != nil {
+			return nil, err
+		}
+
+		var score float64
+		if config.Regression {
+			m, err := EvaluateRegression(t, test)
+			if err != nil {
+				return nil, err
+			}
+			score = m.R2
+		} else {
+			r, err := EvaluateClassification(t, test)
+			if err != nil {
+				return nil, err
+			}
+			score = r.Accuracy
+		}
+		cv.Scores = append(cv.Scores, score)
+	}
+
+	for _, s := range cv.Scores {
+		cv.Mean += s / float64(len(cv.Scores))
+	}
+	for _, s := range cv.Scores {
+		cv.StdDev += (s - cv.Mean) * (s - cv.Mean) / float64(len(cv.Scores))
+	}
+	cv.StdDev = math.Sqrt(cv.StdDev)
+
+	return cv, nil
+}
+
+func trainWith(samples []Sample, train TrainOptions, regression bool) (*Tree, error) {
+	option := func(o *TrainOptions) {
+		*o = train
+	}
+	if regression {
+		return TrainRegression(samples, option)
+	}
+	return Train(samples, option)
+}
+
+// TuneOptions allow to configure the search of the best training configuration
+type TuneOptions struct {
+	CrossValidation CrossValidationOptions
+	// MaxDepths are the tried MaxDepth (2, 3, 4, 6 and no limit by default)
+	MaxDepths []int
+	// MinSamplesLeafs are the tried MinSamplesLeaf (1, 2 and 5 by default)
+	MinSamplesLeafs []int
+	// Criteria are the tried Criterion (InformationGain and GainRatio by default)
+	Criteria []string
+	// Features are the keys of the requests that can be used, all of them by default
+	Features []string
+	// Iterations, if not 0, is the number of configurations randomly chosen in the grid (random search),
+	// all of them are tried by default (grid search)
+	Iterations int
+}
+
+// TuneResult is the cross validation of one training configuration
+type TuneResult struct {
+	Options TrainOptions `json:"options"`
+	CrossValidation
+}
+
+// TuneReport lists the tried configurations, and holds the tree trained with the best one
+type TuneReport struct {
+	Results []TuneResult `json:"results"`
+	Best    TuneResult   `json:"best"`
+	// Tree is trained on all the samples with the best configuration
+	Tree *Tree `json:"-"`
+}
+
+// Tune cross validates each configuration of the grid (or some of them randomly chosen), all of
+// them on the same folds. The best one has the biggest mean score (the smallest standard deviation
+// on equality), the tree is then retrained with it on all the samples.
+func Tune(samples []Sample, options ...func(o *TuneOptions)) (*TuneReport, error) {
+	config := &TuneOptions{}
+	for _, option := range options {
+		option(config)
+	}
+
+	cvConfig := config.CrossValidation
+	if cvConfig.Folds == 0 {
+		cvConfig.Folds = 5
+	}
+	if len(config.MaxDepths) == 0 {
+		config.MaxDepths = []int{2, 3, 4, 6, 0}
+	}
+	if len(config.MinSamplesLeafs) == 0 {
+		config.MinSamplesLeafs = []int{1, 2, 5}
+	}
+	if len(config.Criteria) == 0 {
+		config.Criteria = []string{InformationGain, GainRatio}
+	}
+
+	folds, err := splitFolds(samples, &cvConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var grid []TrainOptions
+	for _, depth := range config.MaxDepths {
+		for _, min := range config.MinSamplesLeafs {
+			for _, criterion := range config.Criteria {
+				grid = append(grid, TrainOptions{MaxDepth: depth, MinSamplesLeaf: min, Criterion: criterion, Features: config.Features})
+			}
+		}
+	}
+
+	if config.Iterations > 0 && config.Iterations < len(grid) {
+		random := rand.New(rand.NewSource(cvConfig.Seed))
+		var chosen []TrainOptions
+		for _, i := range random.Perm(len(grid))[:config.Iterations] {
+			chosen = append(chosen, grid[i])
+		}
+		grid = chosen
+	}
+
+	report := &TuneReport{}
+	for i, train := range grid {
+		cv, err := crossValidate(samples, folds, train, &cvConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%+v: %v", train, err)
+		}
+
+		result := TuneResult{Options: train, CrossValidation: *cv}
+		report.Results = append(report.Results, result)
+		if i == 0 || result.Mean > report.Best.Mean || (result.Mean == report.Best.Mean && result.StdDev < report.Best.StdDev) {
+			report.Best = result
+		}
+	}
+
+	report.Tree, err = trainWith(samples, report.Best.Options, cvConfig.Regression)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
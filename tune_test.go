@@ -0,0 +1,112 @@
+package dtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossValidate(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	cv, err := CrossValidate(samples, TrainOptions{}, func(o *CrossValidationOptions) {
+		o.Folds = 3
+		o.Seed = 7
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, cv.Scores, 3)
+	for _, s := range cv.Scores {
+		assert.True(t, s >= 0 && s <= 1)
+	}
+
+	again, err := CrossValidate(samples, TrainOptions{}, func(o *CrossValidationOptions) {
+		o.Folds = 3
+		o.Seed = 7
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, cv, again, "the same seed gives the same folds")
+}
+
+func TestCrossValidate_Regression(t *testing.T) {
+	// Act
+	cv, err := CrossValidate(deliverySamples(), TrainOptions{}, func(o *CrossValidationOptions) {
+		o.Folds = 4
+		o.Regression = true
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 1, 1, 1}, cv.Scores)
+	assert.Equal(t, 1.0, cv.Mean)
+	assert.Equal(t, 0.0, cv.StdDev)
+}
+
+func TestSplitFolds_Stratified(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+
+	// Act
+	folds, err := splitFolds(samples, &CrossValidationOptions{Folds: 2, Seed: 1})
+
+	// Assert
+	assert.NoError(t, err)
+	counts := map[int]map[interface{}]int{0: {}, 1: {}}
+	for i, f := range folds {
+		counts[f][samples[i].Label]++
+	}
+	// 9 yes and 5 no
+	assert.Equal(t, 9, counts[0]["yes"]+counts[1]["yes"])
+	assert.InDelta(t, counts[0]["yes"], counts[1]["yes"], 1)
+	assert.InDelta(t, counts[0]["no"], counts[1]["no"], 1)
+
+	_, err = splitFolds(samples, &CrossValidationOptions{Folds: 15})
+	assert.Equal(t, ErrFolds, err)
+}
+
+func TestTune(t *testing.T) {
+	// Arrange
+	samples := deliverySamples()
+
+	// Act
+	report, err := Tune(samples, func(o *TuneOptions) {
+		o.CrossValidation.Regression = true
+		o.CrossValidation.Seed = 3
+		o.MaxDepths = []int{1, 2}
+		o.MinSamplesLeafs = []int{1}
+		o.Criteria = []string{InformationGain}
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, report.Results, 2)
+	assert.Equal(t, 2, report.Best.Options.MaxDepth)
+	assert.True(t, report.Results[0].Mean < report.Results[1].Mean)
+
+	m, err := EvaluateRegression(report.Tree, samples)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, m.R2)
+}
+
+func TestTune_RandomSearch(t *testing.T) {
+	// Arrange
+	samples := loadSamples(t, "testdata/weather.json")
+	search := func(o *TuneOptions) {
+		o.CrossValidation.Folds = 2
+		o.CrossValidation.Seed = 11
+		o.Iterations = 4
+	}
+
+	// Act
+	report, err := Tune(samples, search)
+	again, _ := Tune(samples, search)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, report.Results, 4)
+	assert.Equal(t, report.Results, again.Results)
+	assert.NotNil(t, report.Tree)
+}